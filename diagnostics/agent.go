@@ -0,0 +1,391 @@
+package diagnostics
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/weaviate/weaviate-diagnostics/utilities"
+)
+
+// AgentInfo is what an agent running next to a Weaviate node reports about
+// the node it runs on.
+type AgentInfo struct {
+	Node         string
+	Hostname     string
+	Host         HostInfo
+	Env          map[string]string
+	DataDir      *DataDirUsage
+	DataDirError string
+}
+
+// agentLogBytes is how far back from the end of the log file the agent looks
+// for the requested number of lines
+const agentLogBytes = 1 << 20
+
+// secretEnvMarkers are parts of environment variable names whose values the
+// agent does not hand out
+var secretEnvMarkers = []string{"KEY", "SECRET", "PASS", "TOKEN", "CREDENTIAL", "AUTH", "CONNECTION_STRING"}
+
+func redactEnv(env map[string]string) map[string]string {
+	redacted := make(map[string]string, len(env))
+	for name, value := range env {
+		for _, marker := range secretEnvMarkers {
+			if strings.Contains(strings.ToUpper(name), marker) {
+				value = "<redacted>"
+				break
+			}
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+// parseEnviron parses the NUL separated content of /proc/<pid>/environ or
+// the entries of os.Environ.
+func parseEnviron(entries []string) map[string]string {
+	env := map[string]string{}
+	for _, entry := range entries {
+		name, value, found := strings.Cut(entry, "=")
+		if !found || name == "" {
+			continue
+		}
+		env[name] = value
+	}
+	return env
+}
+
+// localWeaviateEnv returns the environment of the local Weaviate process, or
+// nil if there is none or its environment cannot be read.
+func localWeaviateEnv() map[string]string {
+	weaviate := findWeaviateProcess()
+	if weaviate == "" {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(weaviate, "environ"))
+	if err != nil {
+		return nil
+	}
+	return parseEnviron(strings.Split(string(data), "\x00"))
+}
+
+// weaviateEnv returns the environment of the local Weaviate process, which a
+// sidecar can only read if the pod shares its process namespace. The agent's
+// own environment is used otherwise, which usually is a copy of Weaviate's.
+func weaviateEnv() map[string]string {
+	if env := localWeaviateEnv(); env != nil {
+		return env
+	}
+	return parseEnviron(os.Environ())
+}
+
+func getAgentInfo(dataPath string) AgentInfo {
+	info := AgentInfo{
+		Host: getHostInfo(dataPath),
+		Env:  weaviateEnv(),
+	}
+	info.Hostname, _ = os.Hostname()
+
+	// weaviate names its node after CLUSTER_HOSTNAME, which is the pod name
+	// on kubernetes
+	info.Node = info.Env["CLUSTER_HOSTNAME"]
+	if info.Node == "" {
+		info.Node = info.Hostname
+	}
+	info.Env = redactEnv(info.Env)
+
+	dataDir, err := getDataDirUsage(dataPath)
+	if err != nil {
+		info.DataDirError = err.Error()
+	}
+	info.DataDir = dataDir
+
+	return info
+}
+
+// tailFile returns the last lines of a file, reading at most maxBytes from
+// its end.
+func tailFile(path string, lines int, maxBytes int64) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	offset := info.Size() - maxBytes
+	if offset < 0 {
+		offset = 0
+	}
+	data, err := io.ReadAll(io.NewSectionReader(file, offset, info.Size()-offset))
+	if err != nil {
+		return "", err
+	}
+
+	all := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if offset > 0 && len(all) > 0 {
+		// the first line is most likely cut off
+		all = all[1:]
+	}
+	if len(all) > lines {
+		all = all[len(all)-lines:]
+	}
+	return strings.Join(all, "\n"), nil
+}
+
+// resolveDataPath joins a path relative to the data directory and makes sure
+// it does not point outside of it.
+func resolveDataPath(dataPath string, rel string) (string, error) {
+	if rel == "" {
+		return "", fmt.Errorf("path is required")
+	}
+	path := filepath.Join(dataPath, rel)
+	relToData, err := filepath.Rel(dataPath, path)
+	if err != nil || relToData == ".." || strings.HasPrefix(relToData, "../") {
+		return "", fmt.Errorf("%s is outside of the data directory", rel)
+	}
+	return path, nil
+}
+
+func writeAgentText(w http.ResponseWriter, fn func(io.Writer) error) {
+	var out bytes.Buffer
+	if err := fn(&out); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(out.Bytes())
+}
+
+// newAgentHandler serves the node-local collectors:
+//
+//	GET /v1/info                   host info, redacted env and data directory usage
+//	GET /v1/logs?lines=200         tail of the Weaviate log file
+//	GET /v1/lsm?path=<class>/<shard>
+//	GET /v1/commitlogs?path=<class>/<shard>/main.hnsw.commitlog.d
+//
+// Paths are relative to the data directory. If token is set, every request
+// needs it as a bearer token.
+func newAgentHandler(dataPath string, logFile string, token string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/info", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(getAgentInfo(dataPath))
+	})
+
+	mux.HandleFunc("/v1/logs", func(w http.ResponseWriter, r *http.Request) {
+		if logFile == "" {
+			http.Error(w, "no log file configured", http.StatusNotFound)
+			return
+		}
+		lines := 200
+		if value := r.URL.Query().Get("lines"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed <= 0 {
+				http.Error(w, "lines must be a positive number", http.StatusBadRequest)
+				return
+			}
+			lines = parsed
+		}
+		writeAgentText(w, func(out io.Writer) error {
+			tail, err := tailFile(logFile, lines, agentLogBytes)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(out, tail)
+			return err
+		})
+	})
+
+	mux.HandleFunc("/v1/lsm", func(w http.ResponseWriter, r *http.Request) {
+		writeAgentText(w, func(out io.Writer) error {
+			path, err := resolveDataPath(dataPath, r.URL.Query().Get("path"))
+			if err != nil {
+				return err
+			}
+			return utilities.InspectLSM(out, path, true, 50)
+		})
+	})
+
+	mux.HandleFunc("/v1/commitlogs", func(w http.ResponseWriter, r *http.Request) {
+		writeAgentText(w, func(out io.Writer) error {
+			path, err := resolveDataPath(dataPath, r.URL.Query().Get("path"))
+			if err != nil {
+				return err
+			}
+			return utilities.InspectCommitLogs(out, path)
+		})
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if token != "" {
+			given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// checkAgentListen refuses to serve the agent API without a token on
+// anything but a loopback address, as it hands out logs and data directory
+// details.
+func checkAgentListen(listen string, token string) error {
+	if token != "" {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("listening on %s needs --token, only loopback addresses may be served without one", listen)
+}
+
+func RunAgent() {
+	if err := checkAgentListen(globalConfig.AgentListen, globalConfig.AgentToken); err != nil {
+		log.Fatal("Cannot serve agent:", err)
+	}
+
+	handler := newAgentHandler(globalConfig.DataPath, globalConfig.LogFile, globalConfig.AgentToken)
+
+	server := &http.Server{
+		Addr:              globalConfig.AgentListen,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	fmt.Printf("- Serving diagnostics of %s on %s\n", globalConfig.DataPath, globalConfig.AgentListen)
+	if err := server.ListenAndServe(); err != nil {
+		log.Fatal("Cannot serve agent:", err)
+	}
+}
+
+// AgentReport is the result of querying one agent.
+type AgentReport struct {
+	URL   string
+	Info  *AgentInfo
+	Logs  string
+	Error string
+}
+
+// agentURLs expands the {node} placeholder of the agent URLs with the name of
+// every node, URLs without it are used as is.
+func agentURLs(urls []string, nodes []ServerHost) []string {
+	var expanded []string
+	for _, url := range urls {
+		if !strings.Contains(url, "{node}") {
+			expanded = append(expanded, strings.TrimRight(url, "/"))
+			continue
+		}
+		for _, node := range nodes {
+			expanded = append(expanded, strings.TrimRight(strings.ReplaceAll(url, "{node}", node.Name), "/"))
+		}
+	}
+	return expanded
+}
+
+func agentGet(client *http.Client, url string, token string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return body, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+func queryAgent(client *http.Client, url string, token string, logLines int) AgentReport {
+	report := AgentReport{URL: url}
+
+	body, err := agentGet(client, url+"/v1/info", token)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	var info AgentInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		report.Error = fmt.Sprintf("cannot parse agent info: %s", err)
+		return report
+	}
+	report.Info = &info
+
+	// agents without a log file answer with 404, which is not an error
+	if logs, err := agentGet(client, fmt.Sprintf("%s/v1/logs?lines=%d", url, logLines), token); err == nil {
+		report.Logs = string(logs)
+	}
+
+	return report
+}
+
+// queryAgents queries every agent in parallel and attaches the result to the
+// server host of the same name.
+func queryAgents(urls []string, token string, logLines int, hosts []ServerHost) []AgentReport {
+	client := &http.Client{Timeout: 2 * time.Minute}
+
+	reports := make([]AgentReport, len(urls))
+	done := make(chan struct{})
+	for i, url := range urls {
+		go func(i int, url string) {
+			reports[i] = queryAgent(client, url, token, logLines)
+			done <- struct{}{}
+		}(i, url)
+	}
+	for range urls {
+		<-done
+	}
+
+	for i := range reports {
+		if reports[i].Info == nil {
+			continue
+		}
+		for j := range hosts {
+			if hosts[j].Name == reports[i].Info.Node {
+				hosts[j].Agent = reports[i].Info
+			}
+		}
+	}
+
+	return reports
+}
@@ -0,0 +1,114 @@
+package diagnostics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgent(t *testing.T) {
+	dataPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dataPath, "article", "abc", "lsm", "objects"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dataPath, "article", "abc", "lsm", "objects", "segment-1.wal"), []byte("wal"), 0o644))
+
+	logFile := filepath.Join(t.TempDir(), "weaviate.log")
+	var logs strings.Builder
+	for i := 0; i < 300; i++ {
+		logs.WriteString("line\n")
+	}
+	logs.WriteString("<last line>\n")
+	require.NoError(t, os.WriteFile(logFile, []byte(logs.String()), 0o644))
+
+	t.Setenv("CLUSTER_HOSTNAME", "weaviate-0")
+	t.Setenv("AUTHENTICATION_APIKEY_ALLOWED_KEYS", "secret")
+
+	server := httptest.NewServer(newAgentHandler(dataPath, logFile, "token"))
+	defer server.Close()
+
+	t.Run("requires token", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/v1/info")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("rejects paths outside of the data directory", func(t *testing.T) {
+		_, err := agentGet(server.Client(), server.URL+"/v1/lsm?path=../../etc", "token")
+		assert.ErrorContains(t, err, "outside of the data directory")
+	})
+
+	t.Run("inspects lsm stores", func(t *testing.T) {
+		body, err := agentGet(server.Client(), server.URL+"/v1/lsm?path=article/abc", "token")
+		require.NoError(t, err)
+		assert.Contains(t, string(body), "objects")
+	})
+
+	t.Run("fills server hosts", func(t *testing.T) {
+		hosts := []ServerHost{{Name: "weaviate-0"}, {Name: "weaviate-1"}}
+		reports := queryAgents(agentURLs([]string{server.URL + "/"}, hosts), "token", 200, hosts)
+		require.Len(t, reports, 1)
+		require.Empty(t, reports[0].Error)
+
+		require.NotNil(t, hosts[0].Agent)
+		assert.Nil(t, hosts[1].Agent)
+		assert.Equal(t, "<redacted>", hosts[0].Agent.Env["AUTHENTICATION_APIKEY_ALLOWED_KEYS"])
+		assert.Equal(t, int64(3), hosts[0].Agent.DataDir.Total)
+
+		lines := strings.Split(strings.TrimSpace(reports[0].Logs), "\n")
+		assert.Len(t, lines, 200)
+		assert.Equal(t, "<last line>", lines[199])
+	})
+}
+
+func TestRedactEnv(t *testing.T) {
+	env := redactEnv(map[string]string{
+		"AUTHENTICATION_APIKEY_ALLOWED_KEYS": "secret",
+		"AZURE_STORAGE_CONNECTION_STRING":    "AccountKey=secret",
+		"BACKUP_S3_PASS":                     "secret",
+		"CLUSTER_HOSTNAME":                   "weaviate-0",
+	})
+	assert.Equal(t, map[string]string{
+		"AUTHENTICATION_APIKEY_ALLOWED_KEYS": "<redacted>",
+		"AZURE_STORAGE_CONNECTION_STRING":    "<redacted>",
+		"BACKUP_S3_PASS":                     "<redacted>",
+		"CLUSTER_HOSTNAME":                   "weaviate-0",
+	}, env)
+}
+
+func TestCheckAgentListen(t *testing.T) {
+	assert.NoError(t, checkAgentListen("127.0.0.1:7070", ""))
+	assert.NoError(t, checkAgentListen("localhost:7070", ""))
+	assert.NoError(t, checkAgentListen("[::1]:7070", ""))
+	assert.NoError(t, checkAgentListen(":7070", "token"))
+	assert.ErrorContains(t, checkAgentListen(":7070", ""), "needs --token")
+	assert.ErrorContains(t, checkAgentListen("0.0.0.0:7070", ""), "needs --token")
+}
+
+func TestAgentURLs(t *testing.T) {
+	hosts := []ServerHost{{Name: "weaviate-0"}, {Name: "weaviate-1"}}
+	assert.Equal(t, []string{
+		"http://weaviate-0.weaviate-headless:7070",
+		"http://weaviate-1.weaviate-headless:7070",
+		"http://10.0.0.1:7070",
+	}, agentURLs([]string{"http://{node}.weaviate-headless:7070", "http://10.0.0.1:7070/"}, hosts))
+}
+
+func TestTailFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	require.NoError(t, os.WriteFile(path, []byte("first line\nsecond\nthird\n"), 0o644))
+
+	tail, err := tailFile(path, 5, 1024)
+	require.NoError(t, err)
+	assert.Equal(t, "first line\nsecond\nthird", tail)
+
+	// the partial first line is dropped when the file is longer than maxBytes
+	tail, err = tailFile(path, 5, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "third", tail)
+}
@@ -0,0 +1,147 @@
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/weaviate/weaviate-go-client/v4/weaviate"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate/fault"
+)
+
+// backupModules maps the backup modules to the backend name of the backup API
+var backupModules = map[string]string{
+	"backup-filesystem": "filesystem",
+	"backup-s3":         "s3",
+	"backup-gcs":        "gcs",
+	"backup-azure":      "azure",
+}
+
+const backupStatusFailed = "FAILED"
+
+// BackupStatus is the create or restore status of a backup on a backend.
+type BackupStatus struct {
+	ID        string
+	Backend   string
+	Operation string
+	Status    string
+	Path      string
+	Error     string
+}
+
+// BackupReport lists the enabled backup backends and the status of the
+// backups given with --backups.
+type BackupReport struct {
+	Backends []string
+	Backups  []BackupStatus
+	// NotFound are the backup IDs which do not exist on any backend
+	NotFound []string
+}
+
+// getBackupBackends returns the backends of the enabled backup modules.
+func getBackupBackends(modules map[string]interface{}) []string {
+	var backends []string
+	for module := range modules {
+		if backend, ok := backupModules[module]; ok {
+			backends = append(backends, backend)
+		}
+	}
+	sort.Strings(backends)
+	return backends
+}
+
+func isNotFound(err error) bool {
+	var clientErr *fault.WeaviateClientError
+	return errors.As(err, &clientErr) && clientErr.StatusCode == http.StatusNotFound
+}
+
+// getBackupReport queries the create and restore status of every backup ID.
+// An ID is either looked up on every enabled backend or on the one given as
+// backend/id.
+func getBackupReport(client *weaviate.Client, backends []string, ids []string) *BackupReport {
+	report := &BackupReport{Backends: backends}
+
+	for _, id := range ids {
+		idBackends := backends
+		if backend, backupID, ok := strings.Cut(id, "/"); ok {
+			idBackends, id = []string{backend}, backupID
+		}
+
+		found := false
+		for _, backend := range idBackends {
+			created, err := client.Backup().CreateStatusGetter().WithBackend(backend).WithBackupID(id).Do(context.Background())
+			switch {
+			case err == nil:
+				found = true
+				report.Backups = append(report.Backups, backupStatus("create", backend, id, created.Path, created.Status, created.Error))
+			case !isNotFound(err):
+				found = true
+				report.Backups = append(report.Backups, BackupStatus{ID: id, Backend: backend, Operation: "create", Error: err.Error()})
+				continue
+			}
+
+			restored, err := client.Backup().RestoreStatusGetter().WithBackend(backend).WithBackupID(id).Do(context.Background())
+			if err == nil {
+				found = true
+				report.Backups = append(report.Backups, backupStatus("restore", backend, id, restored.Path, restored.Status, restored.Error))
+			}
+		}
+		if !found {
+			report.NotFound = append(report.NotFound, id)
+		}
+	}
+
+	return report
+}
+
+func backupStatus(operation string, backend string, id string, path string, status *string, backupErr string) BackupStatus {
+	backup := BackupStatus{ID: id, Backend: backend, Operation: operation, Path: path, Error: backupErr}
+	if status != nil {
+		backup.Status = *status
+	}
+	return backup
+}
+
+func validateBackups(report *BackupReport) []Validation {
+	var validations []Validation
+	if report == nil {
+		return validations
+	}
+
+	if len(report.Backends) == 0 {
+		validations = append(validations, Validation{
+			Message: "No backup module is enabled",
+			Hint:    "Enable one of <code>backup-s3</code>, <code>backup-gcs</code>, <code>backup-azure</code> or <code>backup-filesystem</code> in <code>ENABLE_MODULES</code> to be able to back up and restore the data",
+		})
+	} else if len(report.Backends) == 1 && report.Backends[0] == "filesystem" {
+		validations = append(validations, Validation{
+			Message: "Backups are only written to the filesystem of the nodes",
+			Hint:    "A filesystem backup is lost together with the node. Use an object storage backend for backups you need to restore after losing a node",
+		})
+	}
+
+	for _, backup := range report.Backups {
+		switch {
+		case backup.Status == backupStatusFailed:
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("Backup %s on backend %s failed to %s: %s", backup.ID, backup.Backend, backup.Operation, html.EscapeString(backup.Error)),
+			})
+		case backup.Status == "" && backup.Error != "":
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("Cannot query backup %s on backend %s: %s", backup.ID, backup.Backend, html.EscapeString(backup.Error)),
+			})
+		}
+	}
+
+	if len(report.NotFound) > 0 {
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("Backups not found on any enabled backend: %s", strings.Join(report.NotFound, ", ")),
+		})
+	}
+
+	return validations
+}
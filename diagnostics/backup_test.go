@@ -0,0 +1,56 @@
+package diagnostics
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackups(t *testing.T) {
+	assert.Equal(t, []string{"filesystem", "s3"}, getBackupBackends(map[string]interface{}{
+		"backup-s3": nil, "backup-filesystem": nil, "text2vec-openai": nil,
+	}))
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v1/backups/s3/nightly":
+			w.Write([]byte(`{"id": "nightly", "backend": "s3", "path": "s3://backups/nightly", "status": "SUCCESS"}`))
+		case "GET /v1/backups/s3/nightly/restore":
+			w.Write([]byte(`{"id": "nightly", "backend": "s3", "status": "FAILED", "error": "class Article already exists"}`))
+		case "GET /v1/backups/s3/broken":
+			w.Write([]byte(`{"id": "broken", "backend": "s3", "status": "FAILED", "error": "access denied"}`))
+		default:
+			assert.True(t, strings.HasPrefix(r.URL.Path, "/v1/backups/"), r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	report := getBackupReport(client, []string{"filesystem", "s3"}, []string{"nightly", "s3/broken", "missing"})
+	assert.Equal(t, []BackupStatus{
+		{ID: "nightly", Backend: "s3", Operation: "create", Status: "SUCCESS", Path: "s3://backups/nightly"},
+		{ID: "nightly", Backend: "s3", Operation: "restore", Status: "FAILED", Error: "class Article already exists"},
+		{ID: "broken", Backend: "s3", Operation: "create", Status: "FAILED", Error: "access denied"},
+	}, report.Backups)
+	assert.Equal(t, []string{"missing"}, report.NotFound)
+
+	assert.Equal(t, []Validation{
+		{Message: "Backup nightly on backend s3 failed to restore: class Article already exists"},
+		{Message: "Backup broken on backend s3 failed to create: access denied"},
+		{Message: "Backups not found on any enabled backend: missing"},
+	}, validateBackups(report))
+
+	validations := validateBackups(&BackupReport{})
+	require.Len(t, validations, 1)
+	assert.Equal(t, "No backup module is enabled", validations[0].Message)
+	assert.Equal(t, "Backups are only written to the filesystem of the nodes",
+		validateBackups(&BackupReport{Backends: []string{"filesystem"}})[0].Message)
+
+	// errors are rendered as HTML
+	assert.Equal(t, "Cannot query backup nightly on backend s3: unexpected &lt;html&gt; response",
+		validateBackups(&BackupReport{Backends: []string{"s3"}, Backups: []BackupStatus{
+			{ID: "nightly", Backend: "s3", Error: "unexpected <html> response"},
+		}})[0].Message)
+}
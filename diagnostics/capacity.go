@@ -0,0 +1,380 @@
+package diagnostics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/weaviate/weaviate-diagnostics/utilities"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+const (
+	// hnswLinkBytes is the size of a link of the HNSW graph
+	hnswLinkBytes = 8
+	// defaultMaxConnections is used if the index config does not say
+	defaultMaxConnections = 32
+	// gomemlimitHeadroom is the factor between the vector index memory and the
+	// recommended GOMEMLIMIT, leaving room for queries, imports and memtables
+	gomemlimitHeadroom = 1.5
+	// gomemlimitShare is the recommended share of GOMEMLIMIT of the node memory
+	gomemlimitShare = 0.8
+)
+
+// VectorIndexEstimate is the estimated memory of a vector index of a class
+// summed over all its shards and replicas.
+type VectorIndexEstimate struct {
+	Class string
+	// Vector is the name of a named vector, empty for the class vector
+	Vector      string
+	IndexType   string
+	Compression string
+	Dimensions  int
+	// DimensionsSource is where the dimensions come from, a sample object or
+	// the module config of the schema
+	DimensionsSource string
+	MaxConnections   int
+	Objects          int64
+	VectorBytes      int64
+	GraphBytes       int64
+}
+
+// TotalBytes is the estimated memory of the vectors and the graph.
+func (e VectorIndexEstimate) TotalBytes() int64 {
+	return e.VectorBytes + e.GraphBytes
+}
+
+// NodeCapacity compares the estimated memory need of a node with what it
+// has, sizes are 0 if unknown.
+type NodeCapacity struct {
+	Node                  string
+	EstimatedBytes        int64
+	RecommendedGOMEMLIMIT int64
+	RecommendedMemory     int64
+	GOMEMLIMIT            int64
+	MemoryLimit           int64
+	MemoryUsed            int64
+}
+
+// CapacityReport estimates the memory of the vector indexes.
+type CapacityReport struct {
+	Indexes []VectorIndexEstimate
+	Nodes   []NodeCapacity
+	// MissingDimensions are vector indexes whose dimensions are unknown
+	MissingDimensions []string
+}
+
+// NodeMemory is the memory a node has and uses as far as known, sizes are 0
+// if unknown.
+type NodeMemory struct {
+	GOMEMLIMIT  int64
+	MemoryLimit int64
+	MemoryUsed  int64
+}
+
+// vectorIndex is a vector index of a class, the class index or the index of
+// a named vector.
+type vectorIndex struct {
+	vector     string
+	vectorizer string
+	indexType  string
+	config     map[string]interface{}
+}
+
+func classVectorIndexes(class *models.Class) []vectorIndex {
+	if len(class.VectorConfig) == 0 {
+		config, _ := class.VectorIndexConfig.(map[string]interface{})
+		return []vectorIndex{{vectorizer: class.Vectorizer, indexType: class.VectorIndexType, config: config}}
+	}
+
+	var indexes []vectorIndex
+	for name, vectorConfig := range class.VectorConfig {
+		index := vectorIndex{vector: name, indexType: vectorConfig.VectorIndexType}
+		index.config, _ = vectorConfig.VectorIndexConfig.(map[string]interface{})
+		if vectorizer, ok := vectorConfig.Vectorizer.(map[string]interface{}); ok {
+			for module := range vectorizer {
+				index.vectorizer = module
+			}
+		}
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(a, b int) bool {
+		return indexes[a].vector < indexes[b].vector
+	})
+	return indexes
+}
+
+// indexKey identifies a vector index in the dimensions map.
+func indexKey(class string, vector string) string {
+	if vector == "" {
+		return class
+	}
+	return class + "/" + vector
+}
+
+// schemaDimensions returns the dimensions configured for the vectorizer,
+// which only some modules support.
+func schemaDimensions(class *models.Class, index vectorIndex) int {
+	moduleConfig, _ := class.ModuleConfig.(map[string]interface{})
+	if index.vector != "" {
+		if vectorizer, ok := class.VectorConfig[index.vector].Vectorizer.(map[string]interface{}); ok {
+			moduleConfig = vectorizer
+		}
+	}
+	config, _ := moduleConfig[index.vectorizer].(map[string]interface{})
+	dimensions, _ := config["dimensions"].(float64)
+	return int(dimensions)
+}
+
+func configEnabled(config map[string]interface{}, key string) (map[string]interface{}, bool) {
+	setting, ok := config[key].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	enabled, _ := setting["enabled"].(bool)
+	return setting, enabled
+}
+
+// vectorBytes estimates the in-memory size of one vector. Compressed indexes
+// keep only the compressed vectors in memory.
+func vectorBytes(config map[string]interface{}, dimensions int) (string, int64) {
+	if pq, ok := configEnabled(config, "pq"); ok {
+		// every segment is one byte, Weaviate defaults to one segment per
+		// dimension if none is configured
+		segments, _ := pq["segments"].(float64)
+		if segments == 0 {
+			segments = float64(dimensions)
+		}
+		return "pq", int64(segments)
+	}
+	if _, ok := configEnabled(config, "bq"); ok {
+		return "bq", int64((dimensions + 7) / 8)
+	}
+	if _, ok := configEnabled(config, "sq"); ok {
+		return "sq", int64(dimensions)
+	}
+	return "", int64(dimensions) * 4
+}
+
+func estimateVectorIndex(class *models.Class, index vectorIndex, dimensions int, objects int64) VectorIndexEstimate {
+	estimate := VectorIndexEstimate{
+		Class:      class.Class,
+		Vector:     index.vector,
+		IndexType:  index.indexType,
+		Dimensions: dimensions,
+		Objects:    objects,
+	}
+	if estimate.IndexType == "" {
+		estimate.IndexType = "hnsw"
+	}
+
+	compression, perVector := vectorBytes(index.config, dimensions)
+	estimate.Compression = compression
+	// flat indexes read the vectors from disk
+	if estimate.IndexType == "flat" {
+		return estimate
+	}
+
+	estimate.MaxConnections = defaultMaxConnections
+	if maxConnections, ok := index.config["maxConnections"].(float64); ok && maxConnections > 0 {
+		estimate.MaxConnections = int(maxConnections)
+	}
+	estimate.VectorBytes = objects * perVector
+	// the lowest layer of the graph has twice as many links as the others,
+	// which hold only a small fraction of the objects
+	estimate.GraphBytes = objects * int64(2*estimate.MaxConnections*hnswLinkBytes)
+	return estimate
+}
+
+func getCapacityReport(classes []*models.Class, nodes []*models.NodeStatus, sampleDimensions map[string]int,
+	memory map[string]NodeMemory,
+) *CapacityReport {
+	report := &CapacityReport{}
+
+	// objects of every class per node, replicas included
+	objects := map[string]map[string]int64{}
+	for _, node := range nodes {
+		for _, shard := range node.Shards {
+			if objects[shard.Class] == nil {
+				objects[shard.Class] = map[string]int64{}
+			}
+			objects[shard.Class][node.Name] += shard.ObjectCount
+		}
+	}
+
+	perNode := map[string]int64{}
+	for _, class := range classes {
+		for _, index := range classVectorIndexes(class) {
+			key := indexKey(class.Class, index.vector)
+			dimensions, source := sampleDimensions[key], "sample"
+			if dimensions == 0 {
+				dimensions, source = schemaDimensions(class, index), "schema"
+			}
+			if dimensions == 0 {
+				if len(objects[class.Class]) > 0 {
+					report.MissingDimensions = append(report.MissingDimensions, key)
+				}
+				continue
+			}
+
+			var classObjects int64
+			for node, count := range objects[class.Class] {
+				perNode[node] += estimateVectorIndex(class, index, dimensions, count).TotalBytes()
+				classObjects += count
+			}
+			estimate := estimateVectorIndex(class, index, dimensions, classObjects)
+			estimate.DimensionsSource = source
+			report.Indexes = append(report.Indexes, estimate)
+		}
+	}
+	sort.Slice(report.Indexes, func(a, b int) bool {
+		return report.Indexes[a].TotalBytes() > report.Indexes[b].TotalBytes()
+	})
+	sort.Strings(report.MissingDimensions)
+
+	for _, node := range nodes {
+		estimated := perNode[node.Name]
+		recommendedGOMEMLIMIT := int64(float64(estimated) * gomemlimitHeadroom)
+		report.Nodes = append(report.Nodes, NodeCapacity{
+			Node:                  node.Name,
+			EstimatedBytes:        estimated,
+			RecommendedGOMEMLIMIT: recommendedGOMEMLIMIT,
+			RecommendedMemory:     int64(float64(recommendedGOMEMLIMIT) / gomemlimitShare),
+			GOMEMLIMIT:            memory[node.Name].GOMEMLIMIT,
+			MemoryLimit:           memory[node.Name].MemoryLimit,
+			MemoryUsed:            memory[node.Name].MemoryUsed,
+		})
+	}
+	sort.Slice(report.Nodes, func(a, b int) bool {
+		return report.Nodes[a].Node < report.Nodes[b].Node
+	})
+
+	return report
+}
+
+// parseGOMEMLIMIT parses a Go memory limit such as "10GiB" or "1073741824",
+// 0 if unset or invalid.
+func parseGOMEMLIMIT(value string) int64 {
+	value = strings.TrimSpace(value)
+	units := []struct {
+		suffix string
+		factor int64
+	}{{"TiB", 1 << 40}, {"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10}, {"B", 1}}
+	factor := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.suffix) {
+			value, factor = strings.TrimSuffix(value, unit.suffix), unit.factor
+			break
+		}
+	}
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return size * factor
+}
+
+// getNodeMemory collects the memory limits and usage of the nodes from the
+// agents, Kubernetes pods, the Docker container or the local Weaviate
+// process, whichever were collected. localEnv is the environment of the local
+// Weaviate process, GOMEMLIMIT is never read from the collector's environment.
+func getNodeMemory(hosts []ServerHost, kube *KubeReport, docker *DockerContainer, collectorHost HostInfo,
+	runtime ServerRuntime, localEnv map[string]string,
+) map[string]NodeMemory {
+	memory := map[string]NodeMemory{}
+
+	// without agents or pods, the environment, container and process only
+	// describe a single node cluster
+	if len(hosts) == 1 {
+		var node NodeMemory
+		if docker != nil {
+			node.GOMEMLIMIT = parseGOMEMLIMIT(docker.Getenv("GOMEMLIMIT"))
+			node.MemoryLimit = docker.MemoryLimit
+		} else if collectorHost.LocalWeaviate {
+			node.GOMEMLIMIT = parseGOMEMLIMIT(localEnv["GOMEMLIMIT"])
+			node.MemoryLimit = collectorHost.MemoryLimit
+			node.MemoryUsed = collectorHost.WeaviateRSS
+		}
+		if runtime.MetricsReceived {
+			node.MemoryUsed = int64(runtime.ResidentBytes)
+		}
+		memory[hosts[0].Name] = node
+	}
+
+	if kube != nil {
+		for _, pod := range kube.Pods {
+			node := memory[pod.Name]
+			for _, container := range pod.Containers {
+				if container.Name != weaviateContainer {
+					continue
+				}
+				node.MemoryLimit = container.MemoryLimit
+				for _, env := range container.Env {
+					if env.Name == "GOMEMLIMIT" {
+						node.GOMEMLIMIT = parseGOMEMLIMIT(env.Value)
+					}
+				}
+			}
+			if pod.Runtime.MetricsReceived {
+				node.MemoryUsed = int64(pod.Runtime.ResidentBytes)
+			}
+			memory[pod.Name] = node
+		}
+	}
+
+	for _, host := range hosts {
+		if host.Agent == nil {
+			continue
+		}
+		node := memory[host.Name]
+		node.GOMEMLIMIT = parseGOMEMLIMIT(host.Agent.Env["GOMEMLIMIT"])
+		node.MemoryLimit = host.Agent.Host.MemoryLimit
+		if node.MemoryLimit == 0 {
+			node.MemoryLimit = host.Agent.Host.MemoryBytes
+		}
+		if host.Agent.Host.WeaviateRSS > 0 {
+			node.MemoryUsed = host.Agent.Host.WeaviateRSS
+		}
+		memory[host.Name] = node
+	}
+
+	return memory
+}
+
+func validateCapacity(report *CapacityReport) []Validation {
+	var validations []Validation
+	if report == nil {
+		return validations
+	}
+
+	compressionHint := "Add memory, enable PQ, BQ or SQ compression on the largest vector indexes or spread the shards over more nodes"
+	for _, node := range report.Nodes {
+		if node.EstimatedBytes == 0 {
+			continue
+		}
+		if node.MemoryLimit > 0 && node.MemoryLimit < node.RecommendedMemory {
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("Node %s has %s of memory, the vector indexes on it need about %s and %s are recommended",
+					node.Node, utilities.FormatBytes(node.MemoryLimit), utilities.FormatBytes(node.EstimatedBytes), utilities.FormatBytes(node.RecommendedMemory)),
+				Hint: compressionHint + ". A node with too little memory is OOM killed while loading or importing",
+			})
+		}
+		if node.GOMEMLIMIT > 0 && node.GOMEMLIMIT < node.EstimatedBytes {
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("Node %s has <code>GOMEMLIMIT</code> set to %s, below the estimated %s of its vector indexes",
+					node.Node, utilities.FormatBytes(node.GOMEMLIMIT), utilities.FormatBytes(node.EstimatedBytes)),
+				Hint: "The garbage collector runs continuously once the heap reaches GOMEMLIMIT, which slows down the node. " + compressionHint,
+			})
+		}
+		if node.GOMEMLIMIT > 0 && node.MemoryLimit > 0 && node.GOMEMLIMIT > node.MemoryLimit {
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("Node %s has <code>GOMEMLIMIT</code> set to %s, above its memory limit of %s",
+					node.Node, utilities.FormatBytes(node.GOMEMLIMIT), utilities.FormatBytes(node.MemoryLimit)),
+				Hint: fmt.Sprintf("The node is OOM killed before the garbage collector reacts. Set <code>GOMEMLIMIT</code> to about %.0f%% of the memory limit", gomemlimitShare*100),
+			})
+		}
+	}
+
+	return validations
+}
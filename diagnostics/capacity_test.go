@@ -0,0 +1,96 @@
+package diagnostics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+func TestCapacity(t *testing.T) {
+	classes := []*models.Class{
+		{Class: "Article", VectorIndexConfig: map[string]interface{}{"maxConnections": 64.0}},
+		{Class: "Product", Vectorizer: "text2vec-openai",
+			ModuleConfig:      map[string]interface{}{"text2vec-openai": map[string]interface{}{"dimensions": 768.0}},
+			VectorIndexConfig: map[string]interface{}{"pq": map[string]interface{}{"enabled": true, "segments": 96.0}}},
+		{Class: "Image", VectorConfig: map[string]models.VectorConfig{
+			"clip":  {VectorIndexType: "hnsw", VectorIndexConfig: map[string]interface{}{"bq": map[string]interface{}{"enabled": true}}},
+			"small": {VectorIndexType: "flat"},
+		}},
+		{Class: "Unknown"},
+	}
+	shard := func(class string, objects int64) *models.NodeShardStatus {
+		return &models.NodeShardStatus{Class: class, ObjectCount: objects}
+	}
+	nodes := []*models.NodeStatus{
+		{Name: "weaviate-0", Shards: []*models.NodeShardStatus{shard("Article", 1000), shard("Product", 500), shard("Unknown", 1)}},
+		{Name: "weaviate-1", Shards: []*models.NodeShardStatus{shard("Article", 1000), shard("Image", 100)}},
+	}
+	dimensions := map[string]int{"Article": 1536, "Image/clip": 512, "Image/small": 64}
+	memory := map[string]NodeMemory{
+		"weaviate-0": {GOMEMLIMIT: 5e6, MemoryLimit: 8e6, MemoryUsed: 7e6},
+		"weaviate-1": {GOMEMLIMIT: 20e6, MemoryLimit: 10e6},
+	}
+
+	report := getCapacityReport(classes, nodes, dimensions, memory)
+	require.Len(t, report.Indexes, 4)
+	assert.Equal(t, VectorIndexEstimate{Class: "Article", IndexType: "hnsw", Dimensions: 1536, DimensionsSource: "sample",
+		MaxConnections: 64, Objects: 2000, VectorBytes: 2000 * 1536 * 4, GraphBytes: 2000 * 2 * 64 * 8}, report.Indexes[0])
+	assert.Equal(t, VectorIndexEstimate{Class: "Product", IndexType: "hnsw", Compression: "pq", Dimensions: 768, DimensionsSource: "schema",
+		MaxConnections: 32, Objects: 500, VectorBytes: 500 * 96, GraphBytes: 500 * 2 * 32 * 8}, report.Indexes[1])
+	assert.Equal(t, int64(100*512/8), report.Indexes[2].VectorBytes)
+	assert.Equal(t, VectorIndexEstimate{Class: "Image", Vector: "small", IndexType: "flat", Dimensions: 64, DimensionsSource: "sample",
+		Objects: 100}, report.Indexes[3])
+	assert.Equal(t, []string{"Unknown"}, report.MissingDimensions)
+
+	require.Len(t, report.Nodes, 2)
+	assert.Equal(t, NodeCapacity{Node: "weaviate-0", EstimatedBytes: 7472000, RecommendedGOMEMLIMIT: 11208000, RecommendedMemory: 14010000,
+		GOMEMLIMIT: 5e6, MemoryLimit: 8e6, MemoryUsed: 7e6}, report.Nodes[0])
+
+	var messages []string
+	for _, validation := range validateCapacity(report) {
+		messages = append(messages, validation.Message)
+	}
+	assert.Equal(t, []string{
+		"Node weaviate-0 has 7.6 MiB of memory, the vector indexes on it need about 7.1 MiB and 13.4 MiB are recommended",
+		"Node weaviate-0 has <code>GOMEMLIMIT</code> set to 4.8 MiB, below the estimated 7.1 MiB of its vector indexes",
+		"Node weaviate-1 has 9.5 MiB of memory, the vector indexes on it need about 6.9 MiB and 12.9 MiB are recommended",
+		"Node weaviate-1 has <code>GOMEMLIMIT</code> set to 19.1 MiB, above its memory limit of 9.5 MiB",
+	}, messages)
+}
+
+func TestNodeMemory(t *testing.T) {
+	assert.Equal(t, int64(10<<30), parseGOMEMLIMIT("10GiB"))
+	assert.Equal(t, int64(512<<20), parseGOMEMLIMIT("512MiB"))
+	assert.Equal(t, int64(1000), parseGOMEMLIMIT("1000"))
+	assert.Zero(t, parseGOMEMLIMIT(""))
+	assert.Zero(t, parseGOMEMLIMIT("10GB"))
+
+	hosts := []ServerHost{
+		{Name: "weaviate-0", Agent: &AgentInfo{Env: map[string]string{"GOMEMLIMIT": "4GiB"}, Host: HostInfo{MemoryBytes: 8 << 30, WeaviateRSS: 3 << 30}}},
+		{Name: "weaviate-1"},
+	}
+	kube := &KubeReport{Pods: []KubePod{{
+		Name:       "weaviate-1",
+		Containers: []KubeContainer{{Name: weaviateContainer, MemoryLimit: 16 << 30, Env: []KubeEnvVar{{Name: "GOMEMLIMIT", Value: "12GiB"}}}},
+		Runtime:    ServerRuntime{MetricsReceived: true, ResidentBytes: 5 << 30},
+	}}}
+	assert.Equal(t, map[string]NodeMemory{
+		"weaviate-0": {GOMEMLIMIT: 4 << 30, MemoryLimit: 8 << 30, MemoryUsed: 3 << 30},
+		"weaviate-1": {GOMEMLIMIT: 12 << 30, MemoryLimit: 16 << 30, MemoryUsed: 5 << 30},
+	}, getNodeMemory(hosts, kube, nil, HostInfo{}, ServerRuntime{}, nil))
+
+	// a single node is described by the container or the local process
+	docker := &DockerContainer{MemoryLimit: 2 << 30, Env: map[string]string{"GOMEMLIMIT": "1500MiB"}}
+	single := getNodeMemory(hosts[1:], nil, docker, HostInfo{}, ServerRuntime{MetricsReceived: true, ResidentBytes: 1 << 30}, nil)
+	assert.Equal(t, NodeMemory{GOMEMLIMIT: 1500 << 20, MemoryLimit: 2 << 30, MemoryUsed: 1 << 30}, single["weaviate-1"])
+
+	localEnv := map[string]string{"GOMEMLIMIT": "1GiB"}
+	local := getNodeMemory(hosts[1:], nil, nil, HostInfo{LocalWeaviate: true, MemoryLimit: 2 << 30}, ServerRuntime{}, localEnv)
+	assert.Equal(t, NodeMemory{GOMEMLIMIT: 1 << 30, MemoryLimit: 2 << 30}, local["weaviate-1"])
+
+	// without a local Weaviate the environment describes the collector only
+	remote := getNodeMemory(hosts[1:], nil, nil, HostInfo{}, ServerRuntime{}, localEnv)
+	assert.Zero(t, remote["weaviate-1"].GOMEMLIMIT)
+}
@@ -0,0 +1,65 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// classSettings holds the class settings the schema model of the vendored
+// Weaviate version does not know yet.
+type classSettings struct {
+	MultiTenancyConfig multiTenancyConfig `json:"multiTenancyConfig"`
+	ReplicationConfig  replicationConfig  `json:"replicationConfig"`
+}
+
+// getWeaviateJSON decodes the response of a GET request to a Weaviate API
+// path into v. Only API key authentication is supported.
+func getWeaviateJSON(weaviateUrl string, apiKey string, path string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(weaviateUrl, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	client := &http.Client{Timeout: time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// getClassSettings reads the settings of every class from the raw /v1/schema
+// response.
+func getClassSettings(weaviateUrl string, apiKey string) (map[string]classSettings, error) {
+	var schema struct {
+		Classes []struct {
+			Class string `json:"class"`
+			classSettings
+		} `json:"classes"`
+	}
+	if err := getWeaviateJSON(weaviateUrl, apiKey, "/v1/schema", &schema); err != nil {
+		return nil, err
+	}
+
+	settings := map[string]classSettings{}
+	for _, class := range schema.Classes {
+		settings[class.Class] = class.classSettings
+	}
+	return settings, nil
+}
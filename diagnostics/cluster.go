@@ -0,0 +1,238 @@
+package diagnostics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// raftVersion is the first Weaviate version using RAFT for the schema and
+// serving /v1/cluster/statistics
+const raftVersion = "1.25.0"
+
+// maxRaftLag is the number of log entries a node may be behind the commit
+// index of the leader before it is flagged as lagging
+const maxRaftLag = 100
+
+const raftStateLeader = "Leader"
+
+// raftNumber is an index or term of the RAFT statistics, which Weaviate
+// reports as string.
+type raftNumber uint64
+
+func (n *raftNumber) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "" || value == "null" {
+		return nil
+	}
+	parsed, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return err
+	}
+	*n = raftNumber(parsed)
+	return nil
+}
+
+type clusterStatistics struct {
+	Statistics []struct {
+		Name          string `json:"name"`
+		Status        string `json:"status"`
+		IsVoter       bool   `json:"isVoter"`
+		LeaderID      string `json:"leaderId"`
+		LeaderAddress string `json:"leaderAddress"`
+		Raft          struct {
+			AppliedIndex        raftNumber  `json:"appliedIndex"`
+			CommitIndex         raftNumber  `json:"commitIndex"`
+			LastLogIndex        raftNumber  `json:"lastLogIndex"`
+			LastContact         string      `json:"lastContact"`
+			State               string      `json:"state"`
+			Term                raftNumber  `json:"term"`
+			LatestConfiguration interface{} `json:"latestConfiguration"`
+		} `json:"raft"`
+	} `json:"statistics"`
+	Synchronized bool `json:"synchronized"`
+}
+
+// RaftNode is the RAFT state of a node as reported by itself.
+type RaftNode struct {
+	Name          string
+	Status        string
+	State         string
+	Voter         bool
+	LeaderID      string
+	LeaderAddress string
+	Term          uint64
+	CommitIndex   uint64
+	AppliedIndex  uint64
+	LastLogIndex  uint64
+	LastContact   string
+	// Peers are the node IDs of the latest RAFT configuration known to the
+	// node
+	Peers []string
+}
+
+// PeerList formats the peers as comma separated list.
+func (n RaftNode) PeerList() string {
+	return strings.Join(n.Peers, ", ")
+}
+
+// ClusterReport describes the RAFT cluster of Weaviate 1.25 and newer.
+type ClusterReport struct {
+	// Synchronized is reported by Weaviate if all nodes applied the same
+	// schema changes
+	Synchronized bool
+	Nodes        []RaftNode
+	// Metrics lists every RAFT related metric
+	Metrics []MetricSample
+}
+
+// configurationPeers returns the node IDs of a RAFT configuration, which is
+// a list of servers with id, address and suffrage.
+func configurationPeers(configuration interface{}) []string {
+	servers, ok := configuration.([]interface{})
+	if !ok {
+		return nil
+	}
+	var peers []string
+	for _, server := range servers {
+		if server, ok := server.(map[string]interface{}); ok {
+			if id, ok := server["id"].(string); ok {
+				peers = append(peers, id)
+			}
+		}
+	}
+	sort.Strings(peers)
+	return peers
+}
+
+func getClusterStatistics(weaviateUrl string, apiKey string) (clusterStatistics, error) {
+	var statistics clusterStatistics
+	err := getWeaviateJSON(weaviateUrl, apiKey, "/v1/cluster/statistics", &statistics)
+	return statistics, err
+}
+
+func getClusterReport(statistics clusterStatistics, families map[string]*dto.MetricFamily) *ClusterReport {
+	report := &ClusterReport{Synchronized: statistics.Synchronized}
+	for _, node := range statistics.Statistics {
+		report.Nodes = append(report.Nodes, RaftNode{
+			Name:          node.Name,
+			Status:        node.Status,
+			State:         node.Raft.State,
+			Voter:         node.IsVoter,
+			LeaderID:      node.LeaderID,
+			LeaderAddress: node.LeaderAddress,
+			Term:          uint64(node.Raft.Term),
+			CommitIndex:   uint64(node.Raft.CommitIndex),
+			AppliedIndex:  uint64(node.Raft.AppliedIndex),
+			LastLogIndex:  uint64(node.Raft.LastLogIndex),
+			LastContact:   node.Raft.LastContact,
+			Peers:         configurationPeers(node.Raft.LatestConfiguration),
+		})
+	}
+	sort.Slice(report.Nodes, func(a, b int) bool {
+		return report.Nodes[a].Name < report.Nodes[b].Name
+	})
+
+	for name := range families {
+		if strings.Contains(name, "raft") {
+			report.Metrics = append(report.Metrics, MetricSample{Name: name, Value: metricValue(families, name)})
+		}
+	}
+	sort.Slice(report.Metrics, func(a, b int) bool {
+		return report.Metrics[a].Name < report.Metrics[b].Name
+	})
+
+	return report
+}
+
+func validateCluster(report *ClusterReport, nodes []ServerHost) []Validation {
+	var validations []Validation
+	if report == nil {
+		return validations
+	}
+
+	reported := map[string]bool{}
+	var leaders []string
+	leaderIDs := map[string]bool{}
+	peerSets := map[string]bool{}
+	for _, node := range report.Nodes {
+		reported[node.Name] = true
+		if node.State == raftStateLeader {
+			leaders = append(leaders, fmt.Sprintf("%s (term %d)", node.Name, node.Term))
+		}
+		if node.LeaderID == "" {
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("Node %s does not know a RAFT leader, it is in state %s", node.Name, node.State),
+				Hint:    "Schema changes fail without a leader. Check that a majority of the voters can reach each other on the RAFT port (8300)",
+			})
+		} else {
+			leaderIDs[node.LeaderID] = true
+		}
+		if len(node.Peers) > 0 {
+			peerSets[node.PeerList()] = true
+		}
+	}
+
+	if len(leaders) > 1 || len(leaderIDs) > 1 || len(peerSets) > 1 {
+		var ids []string
+		for id := range leaderIDs {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("The nodes disagree on the RAFT cluster: leaders %s, followed leaders %s, %d different peer lists",
+				strings.Join(leaders, ", "), strings.Join(ids, ", "), len(peerSets)),
+			Hint: "This is a split brain, usually after nodes were replaced with new IPs or <code>RAFT_JOIN</code> and <code>RAFT_BOOTSTRAP_EXPECT</code> differ between nodes. Compare the peer lists of the nodes",
+		})
+	}
+
+	var commitIndex uint64
+	for _, node := range report.Nodes {
+		if node.State == raftStateLeader && node.CommitIndex > commitIndex {
+			commitIndex = node.CommitIndex
+		}
+	}
+	for _, node := range report.Nodes {
+		if commitIndex > node.AppliedIndex && commitIndex-node.AppliedIndex > maxRaftLag {
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("Node %s lags %d RAFT entries behind the leader, applied index %d of %d",
+					node.Name, commitIndex-node.AppliedIndex, node.AppliedIndex, commitIndex),
+				Hint: "The node serves an outdated schema until it catches up. Check its logs for RAFT errors and its connection to the leader",
+			})
+		}
+	}
+
+	if !report.Synchronized {
+		versions := map[uint64][]string{}
+		for _, node := range report.Nodes {
+			versions[node.AppliedIndex] = append(versions[node.AppliedIndex], node.Name)
+		}
+		var listed []string
+		for version, names := range versions {
+			listed = append(listed, fmt.Sprintf("%d on %s", version, strings.Join(names, ", ")))
+		}
+		sort.Strings(listed)
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("The nodes have different schema versions: %s", strings.Join(listed, "; ")),
+			Hint:    "Nodes with an older schema version reject requests to new classes or properties until they applied the schema changes",
+		})
+	}
+
+	var missing []string
+	for _, node := range nodes {
+		if !reported[node.Name] {
+			missing = append(missing, node.Name)
+		}
+	}
+	if len(missing) > 0 {
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("Nodes without RAFT statistics: %s", strings.Join(missing, ", ")),
+			Hint:    "The nodes are not part of the RAFT cluster or did not respond, check that they joined the cluster",
+		})
+	}
+
+	return validations
+}
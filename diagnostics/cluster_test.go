@@ -0,0 +1,63 @@
+package diagnostics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testClusterStatistics = `{"synchronized": false, "statistics": [
+	{"name": "weaviate-1", "status": "HEALTHY", "isVoter": true, "leaderId": "weaviate-0", "leaderAddress": "10.0.0.1:8300",
+	 "raft": {"state": "Follower", "term": "3", "commitIndex": "500", "appliedIndex": "250", "lastLogIndex": "500", "lastContact": "12ms",
+	          "latestConfiguration": [{"id": "weaviate-1", "address": "10.0.0.2:8300"}, {"id": "weaviate-0", "address": "10.0.0.1:8300"}]}},
+	{"name": "weaviate-0", "status": "HEALTHY", "isVoter": true, "leaderId": "weaviate-0", "leaderAddress": "10.0.0.1:8300",
+	 "raft": {"state": "Leader", "term": "3", "commitIndex": "500", "appliedIndex": "500", "lastLogIndex": "500", "lastContact": "0",
+	          "latestConfiguration": [{"id": "weaviate-0", "address": "10.0.0.1:8300"}, {"id": "weaviate-1", "address": "10.0.0.2:8300"}]}}
+]}`
+
+func TestClusterReport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/cluster/statistics", r.URL.Path)
+		w.Write([]byte(testClusterStatistics))
+	}))
+	defer server.Close()
+
+	statistics, err := getClusterStatistics(server.URL, "")
+	require.NoError(t, err)
+	families, err := parsePrometheusMetrics([]byte("weaviate_raft_apply_total 7\ngo_goroutines 3\n"))
+	require.NoError(t, err)
+
+	report := getClusterReport(statistics, families)
+	require.Len(t, report.Nodes, 2)
+	assert.Equal(t, RaftNode{Name: "weaviate-0", Status: "HEALTHY", State: "Leader", Voter: true, LeaderID: "weaviate-0",
+		LeaderAddress: "10.0.0.1:8300", Term: 3, CommitIndex: 500, AppliedIndex: 500, LastLogIndex: 500, LastContact: "0",
+		Peers: []string{"weaviate-0", "weaviate-1"}}, report.Nodes[0])
+	assert.Equal(t, []MetricSample{{Name: "weaviate_raft_apply_total", Value: 7}}, report.Metrics)
+
+	var messages []string
+	for _, validation := range validateCluster(report, []ServerHost{{Name: "weaviate-0"}, {Name: "weaviate-1"}, {Name: "weaviate-2"}}) {
+		messages = append(messages, validation.Message)
+	}
+	assert.Equal(t, []string{
+		"Node weaviate-1 lags 250 RAFT entries behind the leader, applied index 250 of 500",
+		"The nodes have different schema versions: 250 on weaviate-1; 500 on weaviate-0",
+		"Nodes without RAFT statistics: weaviate-2",
+	}, messages)
+}
+
+func TestValidateClusterSplitBrain(t *testing.T) {
+	report := &ClusterReport{Synchronized: true, Nodes: []RaftNode{
+		{Name: "weaviate-0", State: "Leader", Term: 4, LeaderID: "weaviate-0", Peers: []string{"weaviate-0"}},
+		{Name: "weaviate-1", State: "Leader", Term: 2, LeaderID: "weaviate-1", Peers: []string{"weaviate-1", "weaviate-2"}},
+		{Name: "weaviate-2", State: "Candidate"},
+	}}
+
+	validations := validateCluster(report, nil)
+	require.Len(t, validations, 2)
+	assert.Equal(t, "Node weaviate-2 does not know a RAFT leader, it is in state Candidate", validations[0].Message)
+	assert.Equal(t, "The nodes disagree on the RAFT cluster: leaders weaviate-0 (term 4), weaviate-1 (term 2), followed leaders weaviate-0, weaviate-1, 2 different peer lists",
+		validations[1].Message)
+}
@@ -13,6 +13,9 @@ var rootCmd = &cobra.Command{
 	Use:   "weaviate-diagnostics",
 	Short: "Weaviate Diagnostics",
 	Long:  `A tool to help diagnose issues with Weaviate`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return utilities.SetLogFormat(globalConfig.LogFormat)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Printf("running the root command, see help or -h for available commands\n")
 	},
@@ -27,6 +30,15 @@ var diagnosticsCmd = &cobra.Command{
 	},
 }
 
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Serve node-local diagnostics over HTTP",
+	Long:  `Run next to a Weaviate node, e.g. as a sidecar, and serve its host info, environment, data directory usage, LSM and commit log inspections and logs to the diagnostics command`,
+	Run: func(cmd *cobra.Command, args []string) {
+		RunAgent()
+	},
+}
+
 var profileCmd = &cobra.Command{
 	Use:   "profile",
 	Short: "Generate a CPU profile",
@@ -37,7 +49,19 @@ var profileCmd = &cobra.Command{
 	},
 }
 
+var probeCmd = &cobra.Command{
+	Use:   "probe",
+	Short: "Measure query latencies",
+	Long:  `Run a fetch by id, a filtered where, a BM25, a nearVector and a hybrid query against every class and print their p50, p95 and p99 latencies and errors. With --write, also measure batch imports into a temporary class`,
+	Run: func(cmd *cobra.Command, args []string) {
+		RunProbe(os.Stdout)
+	},
+}
+
 func initCommand() {
+	rootCmd.PersistentFlags().StringVar(&globalConfig.LogFormat,
+		"log-format", "text", "Log format of the utilities commands, text or json")
+
 	diagnosticsCmd.PersistentFlags().StringVarP(&globalConfig.OutputFile,
 		"output", "o", "weaviate-report.html", "File to write the report to")
 	// todo make these configurable
@@ -59,6 +83,117 @@ func initCommand() {
 	diagnosticsCmd.PersistentFlags().StringVarP(&globalConfig.Pass,
 		"pass", "w", "", "Password for OIDC authentication (defaults to prompt)")
 
+	diagnosticsCmd.PersistentFlags().StringVar(&globalConfig.DataPath,
+		"data-path", "/var/lib/weaviate", "Path of the Weaviate data directory to analyze if run on the Weaviate host")
+
+	diagnosticsCmd.PersistentFlags().StringSliceVar(&globalConfig.Agents,
+		"agents", nil, "Agent URLs to collect node-local data from, {node} is replaced with every node name, e.g. http://{node}.weaviate-headless:7070")
+
+	diagnosticsCmd.PersistentFlags().StringVar(&globalConfig.AgentToken,
+		"agent-token", "", "Bearer token of the agents")
+
+	diagnosticsCmd.PersistentFlags().IntVar(&globalConfig.LogLines,
+		"log-lines", 200, "Number of recent log lines to collect per node from agents or pods")
+
+	diagnosticsCmd.PersistentFlags().BoolVar(&globalConfig.Kube,
+		"kube", false, "Find the Weaviate StatefulSet with the kubeconfig, forward to its pods and collect pod specs, events, volumes and logs")
+
+	diagnosticsCmd.PersistentFlags().StringVar(&globalConfig.Kubeconfig,
+		"kubeconfig", "", "Path of the kubeconfig (defaults to $KUBECONFIG or ~/.kube/config)")
+
+	diagnosticsCmd.PersistentFlags().StringVar(&globalConfig.KubeContext,
+		"context", "", "Kubeconfig context to use (defaults to the current context)")
+
+	diagnosticsCmd.PersistentFlags().StringVar(&globalConfig.Namespace,
+		"namespace", "", "Namespace of the Weaviate StatefulSet (defaults to the namespace of the context)")
+
+	diagnosticsCmd.PersistentFlags().StringVar(&globalConfig.StatefulSet,
+		"statefulset", "weaviate", "Name of the Weaviate StatefulSet")
+
+	diagnosticsCmd.PersistentFlags().StringVar(&globalConfig.Docker,
+		"docker", "", "Name or ID of the Weaviate container to collect the config, state and logs of")
+
+	diagnosticsCmd.PersistentFlags().StringVar(&globalConfig.DockerHost,
+		"docker-host", dockerHost(), "Docker Engine API to read the container from")
+
+	diagnosticsCmd.PersistentFlags().StringVar(&globalConfig.Logs,
+		"logs", "", "Weaviate JSON log file, directory of log files or - for stdin to analyze")
+
+	diagnosticsCmd.PersistentFlags().IntVar(&globalConfig.MaxActiveTenants,
+		"max-active-tenants-per-node", 5000, "Flag multi-tenant classes with more active tenants than this on a node")
+
+	diagnosticsCmd.PersistentFlags().StringSliceVar(&globalConfig.Backups,
+		"backups", nil, "Backup IDs to report the status of, either looked up on every enabled backup backend or given as backend/id")
+
+	diagnosticsCmd.PersistentFlags().IntVar(&globalConfig.SampleObjects,
+		"sample-objects", 0, fmt.Sprintf("Number of objects per class to read for property fill rates, text lengths and vector dimensions (at most %d, 0 disables sampling)", maxSampleObjects))
+
+	diagnosticsCmd.PersistentFlags().BoolVar(&globalConfig.SampleVectors,
+		"sample-vectors", false, "Read the vectors of the sampled objects to measure their dimensions")
+
+	diagnosticsCmd.PersistentFlags().BoolVar(&globalConfig.Probe,
+		"probe", false, "Measure query latencies like the probe command and add them to the report")
+
+	diagnosticsCmd.PersistentFlags().StringSliceVar(&globalConfig.ProbeClasses,
+		"probe-classes", nil, "Classes to probe (defaults to all)")
+
+	diagnosticsCmd.PersistentFlags().IntVar(&globalConfig.ProbeRequests,
+		"probe-requests", 20, "Number of requests per probed query")
+
+	diagnosticsCmd.PersistentFlags().IntVar(&globalConfig.ProbeConcurrency,
+		"probe-concurrency", 4, "Number of concurrent requests of the probe")
+
+	diagnosticsCmd.PersistentFlags().BoolVar(&globalConfig.ProbeWrite,
+		"probe-write", false, "Import synthetic objects into a temporary class like probe --write and add the batch latencies to the report")
+
+	diagnosticsCmd.PersistentFlags().IntVar(&globalConfig.ProbeWriteObjects,
+		"probe-write-objects", 100, "Number of objects the write probe imports per consistency level")
+
+	diagnosticsCmd.PersistentFlags().IntVar(&globalConfig.ProbeWriteBatch,
+		"probe-write-batch-size", 20, "Number of objects per batch of the write probe")
+
+	agentCmd.PersistentFlags().StringVar(&globalConfig.AgentListen,
+		"listen", "127.0.0.1:7070", "Address to serve the agent API on, addresses other than loopback need --token")
+
+	agentCmd.PersistentFlags().StringVar(&globalConfig.DataPath,
+		"data-path", "/var/lib/weaviate", "Path of the Weaviate data directory")
+
+	agentCmd.PersistentFlags().StringVar(&globalConfig.LogFile,
+		"log-file", "", "Weaviate log file to serve the tail of")
+
+	agentCmd.PersistentFlags().StringVar(&globalConfig.AgentToken,
+		"token", "", "Require this bearer token on every request")
+
+	probeCmd.PersistentFlags().StringVarP(&globalConfig.Url,
+		"url", "u", "http://localhost:8080", "URL of the Weaviate instance")
+
+	probeCmd.PersistentFlags().StringVarP(&globalConfig.ApiKey,
+		"apiKey", "a", "", "API key authentication")
+
+	probeCmd.PersistentFlags().StringVarP(&globalConfig.User,
+		"user", "n", "", "Username for OIDC authentication")
+
+	probeCmd.PersistentFlags().StringVarP(&globalConfig.Pass,
+		"pass", "w", "", "Password for OIDC authentication (defaults to prompt)")
+
+	probeCmd.PersistentFlags().StringSliceVar(&globalConfig.ProbeClasses,
+		"classes", nil, "Classes to probe (defaults to all)")
+
+	probeCmd.PersistentFlags().IntVar(&globalConfig.ProbeRequests,
+		"requests", 20, "Number of requests per query")
+
+	probeCmd.PersistentFlags().IntVar(&globalConfig.ProbeConcurrency,
+		"concurrency", 4, "Number of concurrent requests")
+
+	probeCmd.PersistentFlags().BoolVar(&globalConfig.ProbeWrite,
+		"write", false, "Also import synthetic objects into a temporary class with every consistency level and delete it again")
+
+	probeCmd.PersistentFlags().IntVar(&globalConfig.ProbeWriteObjects,
+		"write-objects", 100, "Number of objects to import per consistency level")
+
+	probeCmd.PersistentFlags().IntVar(&globalConfig.ProbeWriteBatch,
+		"write-batch-size", 20, "Number of objects per batch")
+
 	profileCmd.PersistentFlags().StringVarP(&globalConfig.ProfileUrl,
 		"profileUrl", "p", "http://localhost:6060/debug/pprof/profile?seconds=5", "URL of the Weaviate pprof endpoint")
 
@@ -67,7 +202,12 @@ func initCommand() {
 
 	rootCmd.AddCommand(diagnosticsCmd)
 	rootCmd.AddCommand(profileCmd)
+	rootCmd.AddCommand(agentCmd)
+	rootCmd.AddCommand(probeCmd)
 	rootCmd.AddCommand(utilities.NewCombineCommitLogCmd())
+	rootCmd.AddCommand(utilities.NewInspectCommitLogCmd())
+	rootCmd.AddCommand(utilities.NewVerifyCommitLogsCmd())
+	rootCmd.AddCommand(utilities.NewInspectLSMCmd())
 }
 
 func Execute() {
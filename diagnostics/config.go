@@ -9,4 +9,30 @@ type Config struct {
 	OutputFile        string
 	User              string
 	Pass              string
+	LogFormat         string
+	DataPath          string
+	Agents            []string
+	AgentToken        string
+	AgentListen       string
+	LogFile           string
+	LogLines          int
+	Kube              bool
+	Kubeconfig        string
+	KubeContext       string
+	Namespace         string
+	StatefulSet       string
+	Docker            string
+	DockerHost        string
+	Logs              string
+	MaxActiveTenants  int
+	Backups           []string
+	SampleObjects     int
+	SampleVectors     bool
+	Probe             bool
+	ProbeClasses      []string
+	ProbeRequests     int
+	ProbeConcurrency  int
+	ProbeWrite        bool
+	ProbeWriteObjects int
+	ProbeWriteBatch   int
 }
@@ -0,0 +1,143 @@
+package diagnostics
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DataDirUsage is the disk usage of a Weaviate data directory broken down by
+// class, shard and component.
+type DataDirUsage struct {
+	Path    string
+	Total   int64
+	Entries []DataDirEntry
+}
+
+type DataDirEntry struct {
+	Class     string
+	Shard     string
+	Component string
+	Bytes     int64
+	Files     int
+}
+
+const (
+	componentCommitLogs        = "HNSW commit logs"
+	componentObjects           = "LSM object store"
+	componentInvertedIndex     = "Inverted index"
+	componentCompressedVectors = "PQ/compressed vectors"
+	componentVectors           = "Vectors"
+	componentBackups           = "Backups"
+	componentMigrations        = "Migrations"
+	componentOther             = "Other"
+)
+
+// classifyDataPath returns the class, shard and component a file below the
+// data directory belongs to, based on the layout Weaviate uses on disk:
+// <root>/<class>/<shard>/{lsm/<bucket>,<name>.hnsw.commitlog.d,...}
+func classifyDataPath(rel string) (string, string, string) {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+
+	if strings.HasPrefix(parts[0], "migration") {
+		return "", "", componentMigrations
+	}
+	if parts[0] == "backups" || strings.Contains(rel, ".bak") {
+		class, shard := "", ""
+		if len(parts) >= 3 && parts[0] != "backups" {
+			class, shard = parts[0], parts[1]
+		}
+		return class, shard, componentBackups
+	}
+	if len(parts) < 3 {
+		// files at the root or directly in a class folder such as schema.db
+		return "", "", componentOther
+	}
+
+	class, shard, rest := parts[0], parts[1], parts[2:]
+	switch {
+	case strings.HasSuffix(rest[0], ".hnsw.commitlog.d"):
+		return class, shard, componentCommitLogs
+	case rest[0] == "lsm" && len(rest) > 2:
+		bucket := rest[1]
+		switch {
+		case bucket == "objects":
+			return class, shard, componentObjects
+		case strings.HasPrefix(bucket, "vectors_compressed"):
+			return class, shard, componentCompressedVectors
+		case strings.HasPrefix(bucket, "vectors"):
+			return class, shard, componentVectors
+		default:
+			return class, shard, componentInvertedIndex
+		}
+	}
+
+	return class, shard, componentOther
+}
+
+func getDataDirUsage(dataPath string) (*DataDirUsage, error) {
+	info, err := os.Stat(dataPath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dataPath)
+	}
+
+	usage := &DataDirUsage{Path: dataPath}
+	entries := map[DataDirEntry]*DataDirEntry{}
+
+	err = filepath.WalkDir(dataPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// files can disappear while weaviate compacts, skip them
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dataPath, path)
+		if err != nil {
+			return err
+		}
+
+		class, shard, component := classifyDataPath(rel)
+		key := DataDirEntry{Class: class, Shard: shard, Component: component}
+		entry, ok := entries[key]
+		if !ok {
+			entry = &DataDirEntry{Class: class, Shard: shard, Component: component}
+			entries[key] = entry
+		}
+		entry.Bytes += info.Size()
+		entry.Files++
+		usage.Total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		usage.Entries = append(usage.Entries, *entry)
+	}
+	sort.Slice(usage.Entries, func(a, b int) bool {
+		if usage.Entries[a].Bytes == usage.Entries[b].Bytes {
+			return usage.Entries[a].Class+usage.Entries[a].Shard+usage.Entries[a].Component <
+				usage.Entries[b].Class+usage.Entries[b].Shard+usage.Entries[b].Component
+		}
+		return usage.Entries[a].Bytes > usage.Entries[b].Bytes
+	})
+
+	return usage, nil
+}
@@ -0,0 +1,34 @@
+package diagnostics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyDataPath(t *testing.T) {
+	tests := []struct {
+		path      string
+		class     string
+		shard     string
+		component string
+	}{
+		{"schema.db", "", "", componentOther},
+		{"article/abc/main.hnsw.commitlog.d/1700000000", "article", "abc", componentCommitLogs},
+		{"article/abc/main.hnsw.commitlog.d.1700000000.bak/1700000000", "article", "abc", componentBackups},
+		{"article/abc/lsm/objects/segment-1.db", "article", "abc", componentObjects},
+		{"article/abc/lsm/property_title/segment-1.db", "article", "abc", componentInvertedIndex},
+		{"article/abc/lsm/property_title_searchable/segment-1.db", "article", "abc", componentInvertedIndex},
+		{"article/abc/lsm/vectors_compressed/segment-1.db", "article", "abc", componentCompressedVectors},
+		{"article/abc/indexcount", "article", "abc", componentOther},
+		{"backups/my-backup/backup_config.json", "", "", componentBackups},
+		{"migration1.19.filter2search.state", "", "", componentMigrations},
+	}
+
+	for _, test := range tests {
+		class, shard, component := classifyDataPath(test.path)
+		assert.Equal(t, test.class, class, test.path)
+		assert.Equal(t, test.shard, shard, test.path)
+		assert.Equal(t, test.component, component, test.path)
+	}
+}
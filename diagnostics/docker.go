@@ -0,0 +1,214 @@
+package diagnostics
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DockerContainer is the configuration and state of the Weaviate container as
+// reported by the Docker Engine API.
+type DockerContainer struct {
+	ID           string
+	Name         string
+	Image        string
+	Status       string
+	Health       string
+	RestartCount int
+	StartedAt    string
+	OOMKilled    bool
+	// MemoryLimit in bytes, 0 if unlimited
+	MemoryLimit int64
+	// CPUs the container may use, 0 if unlimited
+	CPUs   float64
+	Env    map[string]string
+	Mounts []DockerMount
+	Logs   string
+}
+
+type DockerMount struct {
+	Type        string
+	Source      string
+	Destination string
+	ReadWrite   bool
+}
+
+// Getenv looks up an environment variable of the container, secrets are
+// redacted.
+func (c *DockerContainer) Getenv(name string) string {
+	return c.Env[name]
+}
+
+// dockerInspect is the part of GET /containers/{id}/json the report uses.
+type dockerInspect struct {
+	ID           string `json:"Id"`
+	Name         string
+	RestartCount int
+	State        struct {
+		Status    string
+		OOMKilled bool
+		StartedAt string
+		Health    *struct {
+			Status string
+		}
+	}
+	Config struct {
+		Image string
+		Env   []string
+		Tty   bool
+	}
+	HostConfig struct {
+		Memory    int64
+		NanoCpus  int64
+		CpuQuota  int64
+		CpuPeriod int64
+	}
+	Mounts []struct {
+		Type        string
+		Source      string
+		Destination string
+		RW          bool
+	}
+}
+
+// dockerHost returns the engine the docker CLI would talk to.
+func dockerHost() string {
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		return host
+	}
+	return "unix:///var/run/docker.sock"
+}
+
+// newDockerClient returns an HTTP client for the Docker Engine API at host,
+// e.g. unix:///var/run/docker.sock or tcp://localhost:2375, and the base URL
+// to send requests to.
+func newDockerClient(host string) (*http.Client, string, error) {
+	parsed, err := url.Parse(host)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot parse docker host: %w", err)
+	}
+
+	switch parsed.Scheme {
+	case "unix":
+		socket := parsed.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		}
+		return &http.Client{Transport: transport, Timeout: time.Minute}, "http://docker", nil
+	case "tcp", "http":
+		return &http.Client{Timeout: time.Minute}, "http://" + parsed.Host, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported docker host %s", host)
+	}
+}
+
+func dockerGet(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		// the engine answers errors as {"message": "..."}
+		var apiErr struct{ Message string }
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("%s: %s", resp.Status, apiErr.Message)
+		}
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	return body, nil
+}
+
+// demuxDockerLogs strips the 8 byte frame headers the engine puts in front of
+// every chunk of stdout and stderr if the container has no TTY.
+func demuxDockerLogs(data []byte) []byte {
+	var out bytes.Buffer
+	for len(data) >= 8 {
+		size := int(binary.BigEndian.Uint32(data[4:8]))
+		data = data[8:]
+		if size > len(data) {
+			size = len(data)
+		}
+		out.Write(data[:size])
+		data = data[size:]
+	}
+	return out.Bytes()
+}
+
+func getDockerContainer(host string, container string, logLines int) (*DockerContainer, error) {
+	client, baseURL, err := newDockerClient(host)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := dockerGet(client, fmt.Sprintf("%s/containers/%s/json", baseURL, url.PathEscape(container)))
+	if err != nil {
+		return nil, fmt.Errorf("cannot inspect container %s: %w", container, err)
+	}
+	var inspect dockerInspect
+	if err := json.Unmarshal(body, &inspect); err != nil {
+		return nil, fmt.Errorf("cannot parse container %s: %w", container, err)
+	}
+
+	result := &DockerContainer{
+		ID:           inspect.ID,
+		Name:         strings.TrimPrefix(inspect.Name, "/"),
+		Image:        inspect.Config.Image,
+		Status:       inspect.State.Status,
+		RestartCount: inspect.RestartCount,
+		StartedAt:    inspect.State.StartedAt,
+		OOMKilled:    inspect.State.OOMKilled,
+		MemoryLimit:  inspect.HostConfig.Memory,
+		Env:          redactEnv(parseEnviron(inspect.Config.Env)),
+	}
+	if inspect.State.Health != nil {
+		result.Health = inspect.State.Health.Status
+	}
+	switch {
+	case inspect.HostConfig.NanoCpus > 0:
+		result.CPUs = float64(inspect.HostConfig.NanoCpus) / 1e9
+	case inspect.HostConfig.CpuQuota > 0 && inspect.HostConfig.CpuPeriod > 0:
+		result.CPUs = float64(inspect.HostConfig.CpuQuota) / float64(inspect.HostConfig.CpuPeriod)
+	}
+	for _, mount := range inspect.Mounts {
+		result.Mounts = append(result.Mounts, DockerMount{
+			Type:        mount.Type,
+			Source:      mount.Source,
+			Destination: mount.Destination,
+			ReadWrite:   mount.RW,
+		})
+	}
+	sort.Slice(result.Mounts, func(a, b int) bool {
+		return result.Mounts[a].Destination < result.Mounts[b].Destination
+	})
+
+	logs, err := dockerGet(client, fmt.Sprintf("%s/containers/%s/logs?stdout=1&stderr=1&tail=%d",
+		baseURL, url.PathEscape(container), logLines))
+	if err != nil {
+		result.Logs = fmt.Sprintf("cannot retrieve logs: %s", err)
+	} else if inspect.Config.Tty {
+		result.Logs = string(logs)
+	} else {
+		result.Logs = string(demuxDockerLogs(logs))
+	}
+
+	return result, nil
+}
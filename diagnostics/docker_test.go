@@ -0,0 +1,83 @@
+package diagnostics
+
+import (
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dockerInspectJSON = `{
+	"Id": "4fa6e0f0c678",
+	"Name": "/weaviate",
+	"RestartCount": 2,
+	"State": {"Status": "running", "OOMKilled": true, "StartedAt": "2024-05-01T10:00:00Z", "Health": {"Status": "unhealthy"}},
+	"Config": {
+		"Image": "cr.weaviate.io/semitechnologies/weaviate:1.24.10",
+		"Env": ["QUERY_MAXIMUM_RESULTS=50000", "AUTHENTICATION_APIKEY_ALLOWED_KEYS=secret", "PATH=/usr/bin"],
+		"Tty": false
+	},
+	"HostConfig": {"Memory": 4294967296, "NanoCpus": 2000000000},
+	"Mounts": [{"Type": "volume", "Source": "/var/lib/docker/volumes/weaviate_data/_data", "Destination": "/var/lib/weaviate", "RW": true}]
+}`
+
+func dockerLogFrame(stream byte, line string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(line)))
+	return append(header, line...)
+}
+
+func TestGetDockerContainer(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	var logsQuery string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/containers/weaviate/json":
+			w.Write([]byte(dockerInspectJSON))
+		case "/containers/weaviate/logs":
+			logsQuery = r.URL.RawQuery
+			w.Write(dockerLogFrame(1, "{\"msg\":\"started\"}\n"))
+			w.Write(dockerLogFrame(2, "{\"msg\":\"failed\"}\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "No such container: missing"}`))
+		}
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	container, err := getDockerContainer("unix://"+socket, "weaviate", 50)
+	require.NoError(t, err)
+
+	assert.Equal(t, "weaviate", container.Name)
+	assert.Equal(t, "unhealthy", container.Health)
+	assert.Equal(t, int64(4<<30), container.MemoryLimit)
+	assert.Equal(t, 2.0, container.CPUs)
+	assert.Equal(t, "<redacted>", container.Env["AUTHENTICATION_APIKEY_ALLOWED_KEYS"])
+	assert.Equal(t, []DockerMount{{Type: "volume", Source: "/var/lib/docker/volumes/weaviate_data/_data",
+		Destination: "/var/lib/weaviate", ReadWrite: true}}, container.Mounts)
+	assert.Equal(t, "stdout=1&stderr=1&tail=50", logsQuery)
+	assert.Equal(t, "{\"msg\":\"started\"}\n{\"msg\":\"failed\"}\n", container.Logs)
+
+	// the container's environment is validated instead of the local one
+	assert.Contains(t, validateEnvironmentVariables(container.Getenv, ""), Validation{
+		Message: "<code>QUERY_MAXIMUM_RESULTS</code> is set high: 50000",
+	})
+	assert.Equal(t, []Validation{
+		{Message: "Container weaviate was OOM killed, 2 restarts"},
+		{Message: "Container weaviate is unhealthy"},
+	}, validateDocker(container))
+
+	_, err = getDockerContainer("unix://"+socket, "missing", 50)
+	assert.ErrorContains(t, err, "No such container: missing")
+}
@@ -3,7 +3,10 @@ package diagnostics
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"syscall"
 
 	"github.com/pbnjay/memory"
@@ -12,11 +15,44 @@ import (
 type HostInfo struct {
 	OperatingSystem string
 	Architecture    string
+	KernelVersion   string
 	Cores           uint32
-	MemorySizeGB    uint64
+	MemoryBytes     int64
 	DiskUsage       string
+	LoadAverage     string
+
+	// CgroupVersion is 0 if no cgroup limits could be found
+	CgroupVersion int
+	// CPUQuota in cores, 0 if unlimited
+	CPUQuota float64
+	// MemoryLimit in bytes, 0 if unlimited
+	MemoryLimit int64
+	// MemoryUsage of the cgroup in bytes
+	MemoryUsage int64
+
+	// LocalWeaviate is set if a Weaviate process runs on the collector host,
+	// only then does the host information describe the Weaviate server
+	LocalWeaviate bool
+	WeaviateRSS   int64
+	SwapTotal     int64
+	SwapFree      int64
+
+	OpenFilesSoft uint64
+	OpenFilesHard uint64
+
+	MaxMapCount          int64
+	TransparentHugepages string
+
+	FilesystemType string
+	MountOptions   string
 }
 
+// procRoot and sysRoot are variables so tests can point them at fixtures
+var (
+	procRoot = "/proc"
+	sysRoot  = "/sys"
+)
+
 func getDiskUse(diskPath string) string {
 	fs := syscall.Statfs_t{}
 
@@ -32,15 +68,247 @@ func getDiskUse(diskPath string) string {
 	return fmt.Sprintf("Total %d GB, Available: %d GB", fs.Blocks*uint64(fs.Bsize)/1024/1024/1024, fs.Bavail*uint64(fs.Bsize)/1024/1024/1024)
 }
 
-func getHostInfo() HostInfo {
+func readTrimmed(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// parseCgroupV2CPUMax parses the "<quota> <period>" format of cpu.max.
+func parseCgroupV2CPUMax(content string) float64 {
+	fields := strings.Fields(content)
+	if len(fields) != 2 || fields[0] == "max" {
+		return 0
+	}
+	quota, err1 := strconv.ParseFloat(fields[0], 64)
+	period, err2 := strconv.ParseFloat(fields[1], 64)
+	if err1 != nil || err2 != nil || period == 0 {
+		return 0
+	}
+	return quota / period
+}
+
+// parseCgroupLimit parses a memory limit, treating "max" and the huge values
+// cgroup v1 uses for unlimited as no limit.
+func parseCgroupLimit(content string) int64 {
+	if content == "" || content == "max" {
+		return 0
+	}
+	limit, err := strconv.ParseInt(content, 10, 64)
+	if err != nil || limit >= 1<<62 {
+		return 0
+	}
+	return limit
+}
+
+// cgroupPaths maps each cgroup v1 controller, or "" for v2, to the path of
+// the current process from /proc/self/cgroup.
+func cgroupPaths(content string) map[string]string {
+	paths := map[string]string{}
+	for _, line := range strings.Split(content, "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			paths[controller] = parts[2]
+		}
+	}
+	return paths
+}
+
+// firstExisting returns the content of the cgroup file below the controller
+// mount, trying the process' own cgroup before the mount root. Inside a
+// container the root is usually the container's own cgroup.
+func firstExisting(mount string, cgroupPath string, file string) string {
+	for _, dir := range []string{filepath.Join(mount, cgroupPath), mount} {
+		if content := readTrimmed(filepath.Join(dir, file)); content != "" {
+			return content
+		}
+	}
+	return ""
+}
+
+func getCgroupInfo(hostInfo *HostInfo) {
+	paths := cgroupPaths(readTrimmed(filepath.Join(procRoot, "self", "cgroup")))
+	cgroupRoot := filepath.Join(sysRoot, "fs", "cgroup")
+
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err == nil {
+		hostInfo.CgroupVersion = 2
+		hostInfo.CPUQuota = parseCgroupV2CPUMax(firstExisting(cgroupRoot, paths[""], "cpu.max"))
+		hostInfo.MemoryLimit = parseCgroupLimit(firstExisting(cgroupRoot, paths[""], "memory.max"))
+		hostInfo.MemoryUsage = parseCgroupLimit(firstExisting(cgroupRoot, paths[""], "memory.current"))
+		return
+	}
+
+	memoryMount := filepath.Join(cgroupRoot, "memory")
+	if _, err := os.Stat(memoryMount); err != nil {
+		return
+	}
+
+	hostInfo.CgroupVersion = 1
+	hostInfo.MemoryLimit = parseCgroupLimit(firstExisting(memoryMount, paths["memory"], "memory.limit_in_bytes"))
+	hostInfo.MemoryUsage = parseCgroupLimit(firstExisting(memoryMount, paths["memory"], "memory.usage_in_bytes"))
+
+	cpuMount := filepath.Join(cgroupRoot, "cpu")
+	quota, err1 := strconv.ParseFloat(firstExisting(cpuMount, paths["cpu"], "cpu.cfs_quota_us"), 64)
+	period, err2 := strconv.ParseFloat(firstExisting(cpuMount, paths["cpu"], "cpu.cfs_period_us"), 64)
+	if err1 == nil && err2 == nil && quota > 0 && period > 0 {
+		hostInfo.CPUQuota = quota / period
+	}
+}
+
+// parseMeminfo returns the values of /proc/meminfo or /proc/<pid>/status in
+// bytes, keyed by name.
+func parseMeminfo(content string) map[string]int64 {
+	values := map[string]int64{}
+	for _, line := range strings.Split(content, "\n") {
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		n, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			n *= 1024
+		}
+		values[name] = n
+	}
+	return values
+}
+
+// findWeaviateProcess returns the /proc directory of a local process named
+// weaviate, or "" if there is none.
+func findWeaviateProcess() string {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil {
+			continue
+		}
+		pidPath := filepath.Join(procRoot, entry.Name())
+		if readTrimmed(filepath.Join(pidPath, "comm")) == "weaviate" {
+			return pidPath
+		}
+	}
+	return ""
+}
+
+// parseOpenFileLimits reads the "Max open files" row of /proc/<pid>/limits.
+func parseOpenFileLimits(content string) (uint64, uint64, bool) {
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.HasPrefix(line, "Max open files") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "Max open files"))
+		if len(fields) < 2 {
+			return 0, 0, false
+		}
+		soft, err1 := strconv.ParseUint(fields[0], 10, 64)
+		hard, err2 := strconv.ParseUint(fields[1], 10, 64)
+		return soft, hard, err1 == nil && err2 == nil
+	}
+	return 0, 0, false
+}
+
+// parseTransparentHugepages returns the selected mode, e.g. "madvise" for
+// "always [madvise] never".
+func parseTransparentHugepages(content string) string {
+	start := strings.Index(content, "[")
+	end := strings.Index(content, "]")
+	if start < 0 || end < start {
+		return content
+	}
+	return content[start+1 : end]
+}
+
+// parseMountInfo finds the mount containing path in /proc/self/mountinfo and
+// returns its filesystem type and mount options.
+func parseMountInfo(content string, path string) (string, string) {
+	var bestMount, fsType, options string
+	for _, line := range strings.Split(content, "\n") {
+		// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+		pre, post, found := strings.Cut(line, " - ")
+		if !found {
+			continue
+		}
+		preFields := strings.Fields(pre)
+		postFields := strings.Fields(post)
+		if len(preFields) < 6 || len(postFields) < 1 {
+			continue
+		}
+		mountPoint := preFields[4]
+		if mountPoint != "/" && path != mountPoint && !strings.HasPrefix(path, mountPoint+"/") {
+			continue
+		}
+		if len(mountPoint) < len(bestMount) {
+			continue
+		}
+		bestMount = mountPoint
+		fsType = postFields[0]
+		options = preFields[5]
+	}
+	return fsType, options
+}
+
+func getHostInfo(dataPath string) HostInfo {
 	hostInfo := HostInfo{}
 
 	hostInfo.Cores = uint32(runtime.NumCPU())
 
-	hostInfo.MemorySizeGB = memory.TotalMemory() / 1024 / 1024
+	hostInfo.MemoryBytes = int64(memory.TotalMemory())
 
 	hostInfo.OperatingSystem = runtime.GOOS
 	hostInfo.Architecture = runtime.GOARCH
-	hostInfo.DiskUsage = getDiskUse("/var/lib/weaviate")
+	hostInfo.DiskUsage = getDiskUse(dataPath)
+
+	if runtime.GOOS != "linux" {
+		return hostInfo
+	}
+
+	hostInfo.KernelVersion = readTrimmed(filepath.Join(procRoot, "sys", "kernel", "osrelease"))
+	if loadavg := strings.Fields(readTrimmed(filepath.Join(procRoot, "loadavg"))); len(loadavg) >= 3 {
+		hostInfo.LoadAverage = strings.Join(loadavg[:3], " ")
+	}
+
+	getCgroupInfo(&hostInfo)
+
+	meminfo := parseMeminfo(readTrimmed(filepath.Join(procRoot, "meminfo")))
+	hostInfo.SwapTotal = meminfo["SwapTotal"]
+	hostInfo.SwapFree = meminfo["SwapFree"]
+
+	// prefer the limits of a local weaviate process over our own
+	limitsPath := filepath.Join(procRoot, "self", "limits")
+	if weaviate := findWeaviateProcess(); weaviate != "" {
+		hostInfo.LocalWeaviate = true
+		hostInfo.WeaviateRSS = parseMeminfo(readTrimmed(filepath.Join(weaviate, "status")))["VmRSS"]
+		limitsPath = filepath.Join(weaviate, "limits")
+	}
+	hostInfo.OpenFilesSoft, hostInfo.OpenFilesHard, _ = parseOpenFileLimits(readTrimmed(limitsPath))
+
+	if maxMapCount, err := strconv.ParseInt(readTrimmed(filepath.Join(procRoot, "sys", "vm", "max_map_count")), 10, 64); err == nil {
+		hostInfo.MaxMapCount = maxMapCount
+	}
+	hostInfo.TransparentHugepages = parseTransparentHugepages(
+		readTrimmed(filepath.Join(sysRoot, "kernel", "mm", "transparent_hugepage", "enabled")))
+
+	if absPath, err := filepath.Abs(dataPath); err == nil {
+		if resolved, err := filepath.EvalSymlinks(absPath); err == nil {
+			absPath = resolved
+		}
+		hostInfo.FilesystemType, hostInfo.MountOptions = parseMountInfo(
+			readTrimmed(filepath.Join(procRoot, "self", "mountinfo")), absPath)
+	}
+
 	return hostInfo
 }
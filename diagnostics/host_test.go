@@ -0,0 +1,57 @@
+package diagnostics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCgroupV2Info(t *testing.T) {
+	root := t.TempDir()
+	defer func(proc, sys string) { procRoot, sysRoot = proc, sys }(procRoot, sysRoot)
+	procRoot = filepath.Join(root, "proc")
+	sysRoot = filepath.Join(root, "sys")
+
+	cgroup := filepath.Join(sysRoot, "fs", "cgroup")
+	require.NoError(t, os.MkdirAll(filepath.Join(procRoot, "self"), os.ModePerm))
+	require.NoError(t, os.MkdirAll(cgroup, os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(procRoot, "self", "cgroup"), []byte("0::/\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(cgroup, "cgroup.controllers"), []byte("cpu memory"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(cgroup, "cpu.max"), []byte("250000 100000\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(cgroup, "memory.max"), []byte("8589934592\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(cgroup, "memory.current"), []byte("1073741824\n"), 0o644))
+
+	hostInfo := HostInfo{}
+	getCgroupInfo(&hostInfo)
+	assert.Equal(t, 2, hostInfo.CgroupVersion)
+	assert.Equal(t, 2.5, hostInfo.CPUQuota)
+	assert.Equal(t, int64(8589934592), hostInfo.MemoryLimit)
+	assert.Equal(t, int64(1073741824), hostInfo.MemoryUsage)
+}
+
+func TestParseHostFiles(t *testing.T) {
+	assert.Equal(t, float64(0), parseCgroupV2CPUMax("max 100000"))
+	assert.Equal(t, int64(0), parseCgroupLimit("9223372036854771712"))
+
+	meminfo := parseMeminfo("MemTotal:       16318412 kB\nSwapTotal:       2097148 kB\n")
+	assert.Equal(t, int64(2097148*1024), meminfo["SwapTotal"])
+
+	soft, hard, ok := parseOpenFileLimits("Limit                     Soft Limit           Hard Limit           Units\n" +
+		"Max open files            1024                 1048576              files\n")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1024), soft)
+	assert.Equal(t, uint64(1048576), hard)
+
+	assert.Equal(t, "madvise", parseTransparentHugepages("always [madvise] never"))
+
+	mountinfo := "22 1 8:1 / / rw,relatime - ext4 /dev/sda1 rw\n" +
+		"30 22 0:45 / /var/lib/weaviate rw,noatime - nfs4 server:/export rw,vers=4.1\n"
+	fsType, options := parseMountInfo(mountinfo, "/var/lib/weaviate/article")
+	assert.Equal(t, "nfs4", fsType)
+	assert.Equal(t, "rw,noatime", options)
+	fsType, _ = parseMountInfo(mountinfo, "/var/lib/other")
+	assert.Equal(t, "ext4", fsType)
+}
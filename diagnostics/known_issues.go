@@ -0,0 +1,143 @@
+package diagnostics
+
+import (
+	_ "embed"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/weaviate/weaviate/entities/models"
+	"gopkg.in/yaml.v3"
+)
+
+// KnownIssue is a limitation of a range of Weaviate versions, a feature newer
+// versions add. The report calls them version limitations, they are not bugs.
+type KnownIssue struct {
+	ID          string `yaml:"id"`
+	Versions    string `yaml:"versions"`
+	Title       string `yaml:"title"`
+	Description string `yaml:"description"`
+	// Upgrade is the first version without the limitation
+	Upgrade string `yaml:"upgrade"`
+	Link    string `yaml:"link"`
+	// Features limits the issue to clusters using all of these features
+	Features []string `yaml:"features"`
+
+	// Affected are the versions running in the cluster which have the issue
+	Affected []string `yaml:"-"`
+}
+
+// Features a version limitation can be limited to.
+const (
+	featureMultiNode    = "multi-node"
+	featureReplication  = "replication"
+	featureMultiTenancy = "multi-tenancy"
+)
+
+var knownFeatures = map[string]bool{featureMultiNode: true, featureReplication: true, featureMultiTenancy: true}
+
+//go:embed known_issues.yaml
+var knownIssuesFile []byte
+
+func loadKnownIssues(data []byte) ([]KnownIssue, error) {
+	var issues []KnownIssue
+	if err := yaml.Unmarshal(data, &issues); err != nil {
+		return nil, err
+	}
+	for _, issue := range issues {
+		if err := checkVersionRange(issue.Versions); err != nil {
+			return nil, fmt.Errorf("version limitation %s: %w", issue.ID, err)
+		}
+		for _, feature := range issue.Features {
+			if !knownFeatures[feature] {
+				return nil, fmt.Errorf("version limitation %s: unknown feature %q", issue.ID, feature)
+			}
+		}
+	}
+	return issues, nil
+}
+
+// usedFeatures returns the features of knownFeatures the cluster uses.
+func usedFeatures(classes []*models.Class, nodes int) map[string]bool {
+	features := map[string]bool{featureMultiNode: nodes > 1}
+	for _, class := range classes {
+		if class.ReplicationConfig != nil && class.ReplicationConfig.Factor > 1 {
+			features[featureReplication] = true
+		}
+		if class.MultiTenancyConfig != nil && class.MultiTenancyConfig.Enabled {
+			features[featureMultiTenancy] = true
+		}
+	}
+	return features
+}
+
+// matchKnownIssues returns the issues affecting any of the versions whose
+// features are all used.
+func matchKnownIssues(issues []KnownIssue, versions []string, features map[string]bool) []KnownIssue {
+	var matched []KnownIssue
+	for _, issue := range issues {
+		issue.Affected = nil
+		if !usesAll(features, issue.Features) {
+			continue
+		}
+		for _, version := range versions {
+			if version != "" && versionInRange(version, issue.Versions) {
+				issue.Affected = append(issue.Affected, version)
+			}
+		}
+		if len(issue.Affected) > 0 {
+			matched = append(matched, issue)
+		}
+	}
+	return matched
+}
+
+func usesAll(features map[string]bool, required []string) bool {
+	for _, feature := range required {
+		if !features[feature] {
+			return false
+		}
+	}
+	return true
+}
+
+// clusterVersions returns the distinct versions of the cluster and its nodes,
+// which differ during rolling upgrades.
+func clusterVersions(version string, hosts []ServerHost) []string {
+	seen := map[string]bool{}
+	var versions []string
+	add := func(v string) {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			versions = append(versions, v)
+		}
+	}
+	add(version)
+	for _, host := range hosts {
+		add(host.Version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+func validateKnownIssues(issues []KnownIssue) []Validation {
+	if len(issues) == 0 {
+		return nil
+	}
+
+	upgrade := ""
+	var titles []string
+	for _, issue := range issues {
+		titles = append(titles, issue.Title)
+		if issue.Upgrade != "" && compareVersions(issue.Upgrade, upgrade) > 0 {
+			upgrade = issue.Upgrade
+		}
+	}
+	validation := Validation{
+		Message: fmt.Sprintf("The running Weaviate version has %d known limitations: %s", len(issues), strings.Join(titles, "; ")),
+	}
+	if upgrade != "" {
+		validation.Hint = fmt.Sprintf("Upgrade to %s or newer to lift them, see Version Limitations for details", upgrade)
+	}
+	return []Validation{validation}
+}
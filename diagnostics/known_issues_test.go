@@ -0,0 +1,79 @@
+package diagnostics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+func TestKnownIssues(t *testing.T) {
+	// the embedded database has to parse, which rejects unknown operators and
+	// features, and declare valid ranges
+	embedded, err := loadKnownIssues(knownIssuesFile)
+	require.NoError(t, err)
+	require.NotEmpty(t, embedded)
+	for _, issue := range embedded {
+		assert.NotEmpty(t, issue.ID)
+		assert.NotEmpty(t, issue.Title, issue.ID)
+		assert.False(t, versionInRange(issue.Upgrade, issue.Versions), issue.ID)
+	}
+
+	issues, err := loadKnownIssues([]byte(`
+- id: compaction
+  versions: ">=1.24.0 <1.24.6"
+  title: Compaction bug
+  upgrade: "1.24.6"
+- id: old
+  versions: "<1.20.0"
+  title: Old bug
+  upgrade: "1.20.0"
+`))
+	require.NoError(t, err)
+
+	versions := clusterVersions("1.24.2", []ServerHost{{Version: "1.24.2"}, {Version: "1.24.8"}, {}})
+	assert.Equal(t, []string{"1.24.2", "1.24.8"}, versions)
+
+	matched := matchKnownIssues(issues, versions, nil)
+	require.Len(t, matched, 1)
+	assert.Equal(t, "compaction", matched[0].ID)
+	assert.Equal(t, []string{"1.24.2"}, matched[0].Affected)
+
+	assert.Equal(t, []Validation{{
+		Message: "The running Weaviate version has 1 known limitations: Compaction bug",
+		Hint:    "Upgrade to 1.24.6 or newer to lift them, see Version Limitations for details",
+	}}, validateKnownIssues(matched))
+	assert.Empty(t, matchKnownIssues(issues, []string{""}, nil))
+}
+
+func TestKnownIssuesFeatures(t *testing.T) {
+	issues, err := loadKnownIssues([]byte(`
+- id: read-repair
+  versions: "<1.26.0"
+  title: Read repair only
+  features: [replication]
+`))
+	require.NoError(t, err)
+
+	classes := []*models.Class{{Class: "Article", ReplicationConfig: &models.ReplicationConfig{Factor: 1}}}
+	assert.Equal(t, map[string]bool{featureMultiNode: false}, usedFeatures(classes, 1))
+	assert.Empty(t, matchKnownIssues(issues, []string{"1.25.0"}, usedFeatures(classes, 1)))
+
+	classes = append(classes, &models.Class{Class: "Product", ReplicationConfig: &models.ReplicationConfig{Factor: 3}})
+	features := usedFeatures(classes, 3)
+	assert.Equal(t, map[string]bool{featureMultiNode: true, featureReplication: true}, features)
+	assert.Len(t, matchKnownIssues(issues, []string{"1.25.0"}, features), 1)
+}
+
+func TestLoadKnownIssuesRejectsInvalidEntries(t *testing.T) {
+	for _, entry := range []string{
+		`versions: "!=1.24.0"`,
+		`versions: "~1.24"`,
+		`versions: ">="`,
+		`features: [sharding]`,
+	} {
+		_, err := loadKnownIssues([]byte("- id: broken\n  title: Broken\n  " + entry))
+		assert.Error(t, err, entry)
+	}
+}
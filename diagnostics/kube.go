@@ -0,0 +1,478 @@
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// KubeReport is what the --kube mode collects about the Weaviate StatefulSet
+// from the Kubernetes API.
+type KubeReport struct {
+	Namespace     string
+	StatefulSet   string
+	Replicas      int32
+	ReadyReplicas int32
+	Pods          []KubePod
+	PVCs          []KubePVC
+	Events        []KubeEvent
+}
+
+type KubePod struct {
+	Name       string
+	Node       string
+	Phase      string
+	Ready      bool
+	Restarts   int32
+	Containers []KubeContainer
+	Volumes    []string
+	Logs       string
+	// PreviousLogs are the logs of the last terminated container if the
+	// weaviate container restarted
+	PreviousLogs string
+	Runtime      ServerRuntime
+	ForwardError string
+}
+
+type KubeContainer struct {
+	Name                  string
+	Image                 string
+	Requests              string
+	Limits                string
+	MemoryLimit           int64
+	Restarts              int32
+	LastTerminationReason string
+	Env                   []KubeEnvVar
+}
+
+type KubeEnvVar struct {
+	Name  string
+	Value string
+}
+
+type KubePVC struct {
+	Name         string
+	Pod          string
+	Phase        string
+	Capacity     string
+	StorageClass string
+}
+
+type KubeEvent struct {
+	Time    string
+	Type    string
+	Reason  string
+	Object  string
+	Message string
+	Count   int32
+}
+
+// kubeMaxEvents is the number of most recent events kept in the report
+const kubeMaxEvents = 100
+
+// weaviateContainer is the container name the Helm chart uses
+const weaviateContainer = "weaviate"
+
+func newKubeClient(kubeconfig string, context string) (*rest.Config, kubernetes.Interface, string, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules,
+		&clientcmd.ConfigOverrides{CurrentContext: context})
+
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return config, clientset, namespace, nil
+}
+
+// findStatefulSet gets the StatefulSet by name, falling back to the first one
+// labeled like the Helm chart does if there is none of that name.
+func findStatefulSet(ctx context.Context, client kubernetes.Interface, namespace string, name string) (*appsv1.StatefulSet, error) {
+	sts, err := client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		return sts, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	list, err := client.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{LabelSelector: "app=weaviate"})
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Items) == 0 {
+		return nil, fmt.Errorf("no StatefulSet %s or labeled app=weaviate in namespace %s", name, namespace)
+	}
+	return &list.Items[0], nil
+}
+
+func formatResources(resources corev1.ResourceList) string {
+	var parts []string
+	for name, quantity := range resources {
+		parts = append(parts, fmt.Sprintf("%s: %s", name, quantity.String()))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+func getKubeEnv(env []corev1.EnvVar) []KubeEnvVar {
+	literal := map[string]string{}
+	var vars []KubeEnvVar
+	for _, envVar := range env {
+		if envVar.ValueFrom == nil {
+			literal[envVar.Name] = envVar.Value
+			continue
+		}
+		value := "<from field>"
+		switch {
+		case envVar.ValueFrom.SecretKeyRef != nil:
+			value = fmt.Sprintf("<from secret %s/%s>", envVar.ValueFrom.SecretKeyRef.Name, envVar.ValueFrom.SecretKeyRef.Key)
+		case envVar.ValueFrom.ConfigMapKeyRef != nil:
+			value = fmt.Sprintf("<from configmap %s/%s>", envVar.ValueFrom.ConfigMapKeyRef.Name, envVar.ValueFrom.ConfigMapKeyRef.Key)
+		}
+		vars = append(vars, KubeEnvVar{Name: envVar.Name, Value: value})
+	}
+	for name, value := range redactEnv(literal) {
+		vars = append(vars, KubeEnvVar{Name: name, Value: value})
+	}
+	sort.Slice(vars, func(a, b int) bool {
+		return vars[a].Name < vars[b].Name
+	})
+	return vars
+}
+
+func getKubePod(ctx context.Context, client kubernetes.Interface, pod corev1.Pod, logLines int64) KubePod {
+	kubePod := KubePod{
+		Name:  pod.Name,
+		Node:  pod.Spec.NodeName,
+		Phase: string(pod.Status.Phase),
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			kubePod.Ready = condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	statuses := map[string]corev1.ContainerStatus{}
+	for _, status := range pod.Status.ContainerStatuses {
+		statuses[status.Name] = status
+		kubePod.Restarts += status.RestartCount
+	}
+
+	for _, container := range pod.Spec.Containers {
+		kubeContainer := KubeContainer{
+			Name:        container.Name,
+			Image:       container.Image,
+			Requests:    formatResources(container.Resources.Requests),
+			Limits:      formatResources(container.Resources.Limits),
+			MemoryLimit: container.Resources.Limits.Memory().Value(),
+			Env:         getKubeEnv(container.Env),
+		}
+		if status, ok := statuses[container.Name]; ok {
+			kubeContainer.Restarts = status.RestartCount
+			if status.LastTerminationState.Terminated != nil {
+				kubeContainer.LastTerminationReason = status.LastTerminationState.Terminated.Reason
+			}
+		}
+		kubePod.Containers = append(kubePod.Containers, kubeContainer)
+	}
+
+	for _, volume := range pod.Spec.Volumes {
+		switch {
+		case volume.PersistentVolumeClaim != nil:
+			kubePod.Volumes = append(kubePod.Volumes, fmt.Sprintf("%s (pvc %s)", volume.Name, volume.PersistentVolumeClaim.ClaimName))
+		case volume.EmptyDir != nil:
+			kubePod.Volumes = append(kubePod.Volumes, fmt.Sprintf("%s (emptyDir)", volume.Name))
+		case volume.ConfigMap != nil:
+			kubePod.Volumes = append(kubePod.Volumes, fmt.Sprintf("%s (configmap %s)", volume.Name, volume.ConfigMap.Name))
+		case volume.Secret != nil:
+			kubePod.Volumes = append(kubePod.Volumes, fmt.Sprintf("%s (secret %s)", volume.Name, volume.Secret.SecretName))
+		default:
+			kubePod.Volumes = append(kubePod.Volumes, volume.Name)
+		}
+	}
+
+	kubePod.Logs = getKubeLogs(ctx, client, pod.Namespace, pod.Name, logLines, false)
+	if status, ok := statuses[weaviateContainer]; ok && status.RestartCount > 0 {
+		kubePod.PreviousLogs = getKubeLogs(ctx, client, pod.Namespace, pod.Name, logLines, true)
+	}
+
+	return kubePod
+}
+
+func getKubeLogs(ctx context.Context, client kubernetes.Interface, namespace string, pod string, lines int64, previous bool) string {
+	logs, err := client.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container: weaviateContainer,
+		TailLines: &lines,
+		Previous:  previous,
+	}).DoRaw(ctx)
+	if err != nil {
+		return fmt.Sprintf("cannot retrieve logs: %s", err)
+	}
+	return string(logs)
+}
+
+func getKubePVCs(ctx context.Context, client kubernetes.Interface, namespace string, pods []corev1.Pod) []KubePVC {
+	var pvcs []KubePVC
+	for _, pod := range pods {
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim == nil {
+				continue
+			}
+			kubePVC := KubePVC{Name: volume.PersistentVolumeClaim.ClaimName, Pod: pod.Name}
+			pvc, err := client.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, kubePVC.Name, metav1.GetOptions{})
+			if err != nil {
+				kubePVC.Phase = err.Error()
+				pvcs = append(pvcs, kubePVC)
+				continue
+			}
+			kubePVC.Phase = string(pvc.Status.Phase)
+			if capacity, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+				kubePVC.Capacity = capacity.String()
+			} else if request, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+				kubePVC.Capacity = request.String()
+			}
+			if pvc.Spec.StorageClassName != nil {
+				kubePVC.StorageClass = *pvc.Spec.StorageClassName
+			}
+			pvcs = append(pvcs, kubePVC)
+		}
+	}
+	return pvcs
+}
+
+// getKubeEvents returns the most recent events of the StatefulSet, its pods
+// and their volume claims.
+func getKubeEvents(ctx context.Context, client kubernetes.Interface, namespace string, objects map[string]bool) ([]KubeEvent, error) {
+	list, err := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var events []corev1.Event
+	for _, event := range list.Items {
+		if objects[event.InvolvedObject.Name] {
+			events = append(events, event)
+		}
+	}
+
+	eventTime := func(event corev1.Event) time.Time {
+		if !event.LastTimestamp.IsZero() {
+			return event.LastTimestamp.Time
+		}
+		return event.EventTime.Time
+	}
+	sort.Slice(events, func(a, b int) bool {
+		return eventTime(events[a]).After(eventTime(events[b]))
+	})
+	if len(events) > kubeMaxEvents {
+		events = events[:kubeMaxEvents]
+	}
+
+	var kubeEvents []KubeEvent
+	for _, event := range events {
+		kubeEvents = append(kubeEvents, KubeEvent{
+			Time:    eventTime(event).Format(time.RFC3339),
+			Type:    event.Type,
+			Reason:  event.Reason,
+			Object:  fmt.Sprintf("%s/%s", event.InvolvedObject.Kind, event.InvolvedObject.Name),
+			Message: event.Message,
+			Count:   event.Count,
+		})
+	}
+	return kubeEvents, nil
+}
+
+func collectKube(ctx context.Context, client kubernetes.Interface, namespace string, name string, logLines int64) (*KubeReport, error) {
+	sts, err := findStatefulSet(ctx, client, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &KubeReport{
+		Namespace:     namespace,
+		StatefulSet:   sts.Name,
+		ReadyReplicas: sts.Status.ReadyReplicas,
+	}
+	if sts.Spec.Replicas != nil {
+		report.Replicas = *sts.Spec.Replicas
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(sts.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pods.Items, func(a, b int) bool {
+		return pods.Items[a].Name < pods.Items[b].Name
+	})
+
+	objects := map[string]bool{sts.Name: true}
+	for _, pod := range pods.Items {
+		report.Pods = append(report.Pods, getKubePod(ctx, client, pod, logLines))
+		objects[pod.Name] = true
+	}
+
+	report.PVCs = getKubePVCs(ctx, client, namespace, pods.Items)
+	for _, pvc := range report.PVCs {
+		objects[pvc.Name] = true
+	}
+
+	report.Events, err = getKubeEvents(ctx, client, namespace, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// kubePorts are the Weaviate ports forwarded to every pod: REST API, metrics
+// and pprof
+var kubePorts = []string{"0:8080", "0:2112", "0:6060"}
+
+// forwardPod forwards random local ports to the Weaviate ports of a pod and
+// returns the local ports keyed by remote port.
+func forwardPod(config *rest.Config, client kubernetes.Interface, namespace string, pod string, stop chan struct{}) (map[uint16]uint16, error) {
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return nil, err
+	}
+	forwardURL := client.CoreV1().RESTClient().Post().
+		Resource("pods").Namespace(namespace).Name(pod).SubResource("portforward").URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, forwardURL)
+
+	ready := make(chan struct{})
+	forwarder, err := portforward.New(dialer, kubePorts, stop, ready, io.Discard, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- forwarder.ForwardPorts()
+	}()
+
+	select {
+	case <-ready:
+	case err := <-errs:
+		return nil, err
+	case <-time.After(30 * time.Second):
+		return nil, fmt.Errorf("timed out forwarding ports of pod %s", pod)
+	}
+
+	forwarded, err := forwarder.GetPorts()
+	if err != nil {
+		return nil, err
+	}
+	ports := map[uint16]uint16{}
+	for _, port := range forwarded {
+		ports[port.Remote] = port.Local
+	}
+	return ports, nil
+}
+
+// localURL points a configured URL at a forwarded local port, keeping its path
+// and query. The pods serve plain http.
+func localURL(configured string, port uint16) string {
+	parsed, err := url.Parse(configured)
+	if err != nil {
+		return configured
+	}
+	parsed.Scheme = "http"
+	parsed.Host = fmt.Sprintf("localhost:%d", port)
+	return parsed.String()
+}
+
+func getPodRuntime(metricsURL string) ServerRuntime {
+	runtime := ServerRuntime{Source: metricsURL}
+	resp, err := http.Get(metricsURL)
+	if err != nil {
+		return runtime
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return runtime
+	}
+	families, err := parsePrometheusMetrics(data)
+	if err != nil {
+		return runtime
+	}
+	return getServerRuntime(metricsURL, families)
+}
+
+// setupKube collects the Kubernetes resources of the Weaviate StatefulSet and
+// forwards the ports of every running pod. The Weaviate, metrics and profile
+// URLs are pointed at the first running pod. The returned function stops the
+// forwarding.
+func setupKube() (*KubeReport, func(), error) {
+	config, client, namespace, err := newKubeClient(globalConfig.Kubeconfig, globalConfig.KubeContext)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot load kubeconfig: %w", err)
+	}
+	if globalConfig.Namespace != "" {
+		namespace = globalConfig.Namespace
+	}
+
+	report, err := collectKube(context.Background(), client, namespace, globalConfig.StatefulSet, int64(globalConfig.LogLines))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stop := make(chan struct{})
+	stopForwarding := func() { close(stop) }
+
+	forwarded := false
+	for i, pod := range report.Pods {
+		if pod.Phase != string(corev1.PodRunning) {
+			continue
+		}
+		ports, err := forwardPod(config, client, namespace, pod.Name, stop)
+		if err != nil {
+			report.Pods[i].ForwardError = err.Error()
+			continue
+		}
+
+		report.Pods[i].Runtime = getPodRuntime(localURL(globalConfig.MetricsUrl, ports[2112]))
+		if !forwarded {
+			globalConfig.Url = localURL(globalConfig.Url, ports[8080])
+			globalConfig.MetricsUrl = localURL(globalConfig.MetricsUrl, ports[2112])
+			globalConfig.ProfileUrl = localURL(globalConfig.ProfileUrl, ports[6060])
+			forwarded = true
+		}
+	}
+	if !forwarded {
+		stopForwarding()
+		return nil, nil, fmt.Errorf("no running pod of StatefulSet %s to forward to", report.StatefulSet)
+	}
+
+	return report, stopForwarding, nil
+}
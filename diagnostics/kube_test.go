@@ -0,0 +1,157 @@
+package diagnostics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func kubeObjects() []runtime.Object {
+	replicas := int32(2)
+	labels := map[string]string{"app": "weaviate"}
+	storageClass := "premium-rwo"
+
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "weaviate", Namespace: "db", Labels: labels},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+		},
+		Status: appsv1.StatefulSetStatus{ReadyReplicas: 1},
+	}
+
+	pod := func(name string, restarts int32, reason string) *corev1.Pod {
+		status := corev1.ContainerStatus{Name: "weaviate", RestartCount: restarts}
+		if reason != "" {
+			status.LastTerminationState.Terminated = &corev1.ContainerStateTerminated{Reason: reason}
+		}
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "db", Labels: labels},
+			Spec: corev1.PodSpec{
+				NodeName: "node-" + name,
+				Containers: []corev1.Container{{
+					Name:  "weaviate",
+					Image: "semitechnologies/weaviate:1.24.10",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+						Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Gi")},
+					},
+					Env: []corev1.EnvVar{
+						{Name: "GOMEMLIMIT", Value: "7GiB"},
+						{Name: "AUTHENTICATION_APIKEY_ALLOWED_KEYS", Value: "secret"},
+						{Name: "OPENAI_APIKEY", ValueFrom: &corev1.EnvVarSource{
+							SecretKeyRef: &corev1.SecretKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{Name: "openai"}, Key: "key"}}},
+					},
+				}},
+				Volumes: []corev1.Volume{{
+					Name: "weaviate-data",
+					VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: "weaviate-data-" + name}},
+				}},
+			},
+			Status: corev1.PodStatus{
+				Phase:             corev1.PodRunning,
+				Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+				ContainerStatuses: []corev1.ContainerStatus{status},
+			},
+		}
+	}
+
+	pvc := func(name string, phase corev1.PersistentVolumeClaimPhase) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "db"},
+			Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: &storageClass},
+			Status: corev1.PersistentVolumeClaimStatus{
+				Phase:    phase,
+				Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("100Gi")},
+			},
+		}
+	}
+
+	event := func(name string, object string, reason string, age time.Duration) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "db"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: object},
+			Reason:         reason,
+			Type:           corev1.EventTypeWarning,
+			LastTimestamp:  metav1.NewTime(time.Now().Add(-age)),
+			Count:          1,
+		}
+	}
+
+	return []runtime.Object{
+		sts,
+		pod("weaviate-0", 0, ""),
+		pod("weaviate-1", 3, "OOMKilled"),
+		pvc("weaviate-data-weaviate-0", corev1.ClaimBound),
+		pvc("weaviate-data-weaviate-1", corev1.ClaimPending),
+		event("e1", "weaviate-1", "BackOff", time.Minute),
+		event("e2", "weaviate-1", "OOMKilling", time.Hour),
+		event("e3", "other-app", "BackOff", time.Minute),
+	}
+}
+
+func TestCollectKube(t *testing.T) {
+	client := fake.NewSimpleClientset(kubeObjects()...)
+
+	// the StatefulSet is found by label if the name does not match
+	kube, err := collectKube(context.Background(), client, "db", "my-weaviate", 100)
+	require.NoError(t, err)
+
+	assert.Equal(t, "weaviate", kube.StatefulSet)
+	assert.Equal(t, int32(2), kube.Replicas)
+	require.Len(t, kube.Pods, 2)
+
+	pod := kube.Pods[1]
+	assert.Equal(t, "weaviate-1", pod.Name)
+	assert.Equal(t, "node-weaviate-1", pod.Node)
+	assert.True(t, pod.Ready)
+	assert.Equal(t, int32(3), pod.Restarts)
+	assert.Equal(t, []string{"weaviate-data (pvc weaviate-data-weaviate-1)"}, pod.Volumes)
+	assert.Equal(t, "fake logs", pod.Logs)
+	assert.Equal(t, "fake logs", pod.PreviousLogs)
+	assert.Empty(t, kube.Pods[0].PreviousLogs)
+
+	require.Len(t, pod.Containers, 1)
+	container := pod.Containers[0]
+	assert.Equal(t, "cpu: 2", container.Requests)
+	assert.Equal(t, "memory: 8Gi", container.Limits)
+	assert.Equal(t, int64(8<<30), container.MemoryLimit)
+	assert.Equal(t, "OOMKilled", container.LastTerminationReason)
+	assert.Equal(t, []KubeEnvVar{
+		{Name: "AUTHENTICATION_APIKEY_ALLOWED_KEYS", Value: "<redacted>"},
+		{Name: "GOMEMLIMIT", Value: "7GiB"},
+		{Name: "OPENAI_APIKEY", Value: "<from secret openai/key>"},
+	}, container.Env)
+
+	assert.Equal(t, []KubePVC{
+		{Name: "weaviate-data-weaviate-0", Pod: "weaviate-0", Phase: "Bound", Capacity: "100Gi", StorageClass: "premium-rwo"},
+		{Name: "weaviate-data-weaviate-1", Pod: "weaviate-1", Phase: "Pending", Capacity: "100Gi", StorageClass: "premium-rwo"},
+	}, kube.PVCs)
+
+	require.Len(t, kube.Events, 2)
+	assert.Equal(t, "BackOff", kube.Events[0].Reason)
+	assert.Equal(t, "Pod/weaviate-1", kube.Events[0].Object)
+
+	assert.Equal(t, []Validation{
+		{Message: "Only 1 of 2 replicas of StatefulSet weaviate are ready"},
+		{Message: "Pod weaviate-1 was OOM killed, 3 restarts"},
+		{Message: "Volume claim weaviate-data-weaviate-1 of pod weaviate-1 is Pending"},
+	}, validateKube(kube))
+}
+
+func TestLocalURL(t *testing.T) {
+	assert.Equal(t, "http://localhost:5000/debug/pprof/profile?seconds=5",
+		localURL("http://localhost:6060/debug/pprof/profile?seconds=5", 5000))
+	assert.Equal(t, "http://localhost:5001", localURL("https://weaviate.example.com", 5001))
+}
@@ -0,0 +1,317 @@
+package diagnostics
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LogAnalysis summarizes Weaviate's JSON logrus output: warnings and errors
+// clustered by message template, and the known-bad patterns found.
+type LogAnalysis struct {
+	Source   string
+	Lines    int
+	Unparsed int
+	// TooLong counts the lines over maxLogLineBytes, which are skipped
+	TooLong  int
+	Levels   map[string]int
+	Clusters []LogCluster
+	Patterns []LogPatternMatch
+}
+
+// LogCluster is a group of warnings or errors with the same message template.
+type LogCluster struct {
+	Level     string
+	Template  string
+	Example   string
+	Count     int
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+type LogPatternMatch struct {
+	Name      string
+	Hint      string
+	Count     int
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Example   string
+}
+
+type logPattern struct {
+	Name  string
+	Regex *regexp.Regexp
+	Hint  string
+	// MinCount is the number of matches from which on the pattern is reported,
+	// some messages are only a problem if they repeat
+	MinCount int
+}
+
+// knownLogPatterns are matched against the message and error of every JSON
+// log line and against the whole line of the ones which are not JSON, such as
+// Go runtime crashes.
+var knownLogPatterns = []logPattern{
+	{
+		Name:     "Out of memory",
+		Regex:    regexp.MustCompile(`(?i)out of memory|cannot allocate memory|OOMKilled|oom-kill`),
+		Hint:     "Weaviate ran out of memory and restarted. Set <code>GOMEMLIMIT</code> below the memory limit and check the vector cache size",
+		MinCount: 1,
+	},
+	{
+		Name:     "Corrupted commit log",
+		Regex:    regexp.MustCompile(`(?i)commit ?log.*(corrupt|truncat|unexpected EOF)|corrupt.*commit ?log`),
+		Hint:     "An HNSW commit log is damaged, check it with <code>verify-commit-logs</code>",
+		MinCount: 1,
+	},
+	{
+		Name:     "Compaction failure",
+		Regex:    regexp.MustCompile(`(?i)compact\w*.*(fail|error)|(fail|error).*compact`),
+		Hint:     "LSM or commit log compaction fails, segments pile up. Check disk space and inspect the buckets with <code>inspect-lsm</code>",
+		MinCount: 1,
+	},
+	{
+		Name:     "RAFT leader churn",
+		Regex:    regexp.MustCompile(`(?i)leader changed|lost leadership|entering (candidate|follower) state|election timeout|heartbeat timeout`),
+		Hint:     "The cluster keeps electing new leaders, which usually points to network issues or overloaded nodes",
+		MinCount: 3,
+	},
+	{
+		Name:     "Replication timeout",
+		Regex:    regexp.MustCompile(`(?i)replica\w*.*(timeout|timed out|deadline exceeded)|(timeout|timed out|deadline exceeded).*replica`),
+		Hint:     "Replicas do not answer in time, check the load and connectivity of the other nodes",
+		MinCount: 1,
+	},
+	{
+		Name:     "Disk full",
+		Regex:    regexp.MustCompile(`(?i)no space left on device`),
+		Hint:     "The data volume is full, Weaviate cannot flush memtables or compact",
+		MinCount: 1,
+	},
+	{
+		Name:     "Too many open files",
+		Regex:    regexp.MustCompile(`(?i)too many open files`),
+		Hint:     "The open files limit is too low for the number of segments, raise <code>ulimit -n</code>",
+		MinCount: 1,
+	},
+}
+
+// maxLogClusters is the number of most frequent clusters kept in the report
+const maxLogClusters = 100
+
+const maxLogLineBytes = 1024 * 1024
+
+var logTemplateReplacements = []struct {
+	regex       *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), "<uuid>"},
+	{regexp.MustCompile(`"[^"]*"`), `"<*>"`},
+	{regexp.MustCompile(`(/[\w.\-]+){2,}/?`), "<path>"},
+	{regexp.MustCompile(`\b[0-9a-f]{12,}\b`), "<id>"},
+	{regexp.MustCompile(`\b\d+(\.\d+)?(ms|s|m|h|µs|ns)?\b`), "<n>"},
+}
+
+// logTemplate replaces the variable parts of a message, such as ids, paths
+// and numbers, so that messages of the same origin end up in one cluster.
+func logTemplate(message string) string {
+	for _, replacement := range logTemplateReplacements {
+		message = replacement.regex.ReplaceAllString(message, replacement.replacement)
+	}
+	return message
+}
+
+type logEntry struct {
+	Level   string `json:"level"`
+	Message string `json:"msg"`
+	Time    string `json:"time"`
+	Error   string `json:"error"`
+}
+
+func newLogAnalysis(source string) *LogAnalysis {
+	return &LogAnalysis{Source: source, Levels: map[string]int{}}
+}
+
+func (a *LogAnalysis) addLine(line string, clusters map[string]*LogCluster, patterns map[string]*LogPatternMatch) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	a.Lines++
+
+	var entry logEntry
+	var seen time.Time
+	// patterns are matched against the message and error of JSON lines, so
+	// that they do not match across fields such as the action and the level
+	text := line
+	message := ""
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		a.Unparsed++
+	} else {
+		seen, _ = time.Parse(time.RFC3339Nano, entry.Time)
+		a.Levels[entry.Level]++
+		message = entry.Message
+		if entry.Error != "" {
+			message += ": " + entry.Error
+		}
+		text = message
+	}
+
+	for _, pattern := range knownLogPatterns {
+		if !pattern.Regex.MatchString(text) {
+			continue
+		}
+		match, ok := patterns[pattern.Name]
+		if !ok {
+			match = &LogPatternMatch{Name: pattern.Name, Hint: pattern.Hint, Example: line}
+			patterns[pattern.Name] = match
+		}
+		match.Count++
+		updateSeen(&match.FirstSeen, &match.LastSeen, seen)
+	}
+
+	switch entry.Level {
+	case "warning", "error", "fatal", "panic":
+	default:
+		return
+	}
+
+	key := entry.Level + " " + logTemplate(message)
+	cluster, ok := clusters[key]
+	if !ok {
+		cluster = &LogCluster{Level: entry.Level, Template: logTemplate(message), Example: message}
+		clusters[key] = cluster
+	}
+	cluster.Count++
+	updateSeen(&cluster.FirstSeen, &cluster.LastSeen, seen)
+}
+
+func updateSeen(first *time.Time, last *time.Time, seen time.Time) {
+	if seen.IsZero() {
+		return
+	}
+	if first.IsZero() || seen.Before(*first) {
+		*first = seen
+	}
+	if seen.After(*last) {
+		*last = seen
+	}
+}
+
+// analyzeLogs reads Weaviate logs from readers in order.
+func analyzeLogs(source string, readers ...io.Reader) (*LogAnalysis, error) {
+	analysis := newLogAnalysis(source)
+	clusters := map[string]*LogCluster{}
+	patterns := map[string]*LogPatternMatch{}
+
+	for _, reader := range readers {
+		buffered := bufio.NewReaderSize(reader, maxLogLineBytes)
+		for {
+			line, isPrefix, err := buffered.ReadLine()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !isPrefix {
+				analysis.addLine(string(line), clusters, patterns)
+				continue
+			}
+			// a single oversized line, e.g. a huge error, must not drop
+			// the whole analysis, so the rest of it is skipped
+			for isPrefix && err == nil {
+				_, isPrefix, err = buffered.ReadLine()
+			}
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			analysis.Lines++
+			analysis.TooLong++
+		}
+	}
+
+	for _, cluster := range clusters {
+		analysis.Clusters = append(analysis.Clusters, *cluster)
+	}
+	sort.Slice(analysis.Clusters, func(a, b int) bool {
+		if analysis.Clusters[a].Count == analysis.Clusters[b].Count {
+			return analysis.Clusters[a].Template < analysis.Clusters[b].Template
+		}
+		return analysis.Clusters[a].Count > analysis.Clusters[b].Count
+	})
+	if len(analysis.Clusters) > maxLogClusters {
+		analysis.Clusters = analysis.Clusters[:maxLogClusters]
+	}
+
+	for _, pattern := range knownLogPatterns {
+		if match, ok := patterns[pattern.Name]; ok && match.Count >= pattern.MinCount {
+			analysis.Patterns = append(analysis.Patterns, *match)
+		}
+	}
+
+	return analysis, nil
+}
+
+// readLogs analyzes a log file, every file of a directory, or stdin for "-".
+func readLogs(path string) (*LogAnalysis, error) {
+	if path == "-" {
+		return analyzeLogs("stdin", os.Stdin)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return analyzeLogs(path, file)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var readers []io.Reader
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		file, err := os.Open(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		readers = append(readers, file)
+	}
+	return analyzeLogs(path, readers...)
+}
+
+func validateLogs(analysis *LogAnalysis) []Validation {
+	var validations []Validation
+	if analysis == nil {
+		return validations
+	}
+
+	for _, match := range analysis.Patterns {
+		seen := ""
+		if !match.FirstSeen.IsZero() {
+			seen = fmt.Sprintf(" between %s and %s", match.FirstSeen.Format(time.RFC3339), match.LastSeen.Format(time.RFC3339))
+		}
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("Logs: %s seen %d times%s", match.Name, match.Count, seen),
+			Hint:    match.Hint,
+		})
+	}
+
+	return validations
+}
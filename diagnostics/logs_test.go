@@ -0,0 +1,94 @@
+package diagnostics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleLogs = `{"action":"startup","level":"info","msg":"configured versions","time":"2024-05-01T10:00:00Z"}
+{"action":"lsm_compaction","level":"error","msg":"compaction failed","error":"open /var/lib/weaviate/article/abc/lsm/objects/segment-1714557600.db: no such file","time":"2024-05-01T10:01:00Z"}
+{"action":"lsm_compaction","level":"error","msg":"compaction failed","error":"open /var/lib/weaviate/article/def/lsm/objects/segment-1714557700.db: no such file","time":"2024-05-01T10:02:00Z"}
+{"level":"warning","msg":"replica 8cb4cbe4-6d7d-4a1c-9d3a-07b8c3b7d0e2 timed out after 30s","time":"2024-05-01T10:03:00Z"}
+{"level":"warning","msg":"raft: heartbeat timeout reached, starting election","time":"2024-05-01T10:04:00Z"}
+{"level":"warning","msg":"raft: heartbeat timeout reached, starting election","time":"2024-05-01T10:05:00Z"}
+
+fatal error: runtime: out of memory
+`
+
+func TestLogTemplate(t *testing.T) {
+	assert.Equal(t, "shard <id> of class \"<*>\" took <n> for <n> objects",
+		logTemplate(`shard 1a2b3c4d5e6f of class "Article" took 1.5s for 100 objects`))
+	assert.Equal(t, "cannot open <path>: <uuid>",
+		logTemplate("cannot open /var/lib/weaviate/article/abc: 8cb4cbe4-6d7d-4a1c-9d3a-07b8c3b7d0e2"))
+}
+
+func TestAnalyzeLogs(t *testing.T) {
+	analysis, err := analyzeLogs("test", strings.NewReader(sampleLogs))
+	require.NoError(t, err)
+
+	assert.Equal(t, 7, analysis.Lines)
+	assert.Equal(t, 1, analysis.Unparsed)
+	assert.Equal(t, map[string]int{"info": 1, "error": 2, "warning": 3}, analysis.Levels)
+
+	require.Len(t, analysis.Clusters, 3)
+	compaction := analysis.Clusters[0]
+	assert.Equal(t, "error", compaction.Level)
+	assert.Equal(t, "compaction failed: open <path>: no such file", compaction.Template)
+	assert.Equal(t, 2, compaction.Count)
+	assert.Equal(t, "2024-05-01T10:01:00Z", compaction.FirstSeen.Format("2006-01-02T15:04:05Z07:00"))
+	assert.Equal(t, "2024-05-01T10:02:00Z", compaction.LastSeen.Format("2006-01-02T15:04:05Z07:00"))
+
+	var names []string
+	for _, match := range analysis.Patterns {
+		names = append(names, match.Name)
+	}
+	// two heartbeat timeouts are below the threshold of leader churn
+	assert.Equal(t, []string{"Out of memory", "Compaction failure", "Replication timeout"}, names)
+
+	validations := validateLogs(analysis)
+	require.Len(t, validations, 3)
+	assert.Equal(t, "Logs: Out of memory seen 1 times", validations[0].Message)
+	assert.Equal(t, "Logs: Compaction failure seen 2 times between 2024-05-01T10:01:00Z and 2024-05-01T10:02:00Z", validations[1].Message)
+	assert.Equal(t, knownLogPatterns[0].Hint, validations[0].Hint)
+}
+
+func TestAnalyzeLogsTooLong(t *testing.T) {
+	logs := sampleLogs + strings.Repeat("x", maxLogLineBytes+10) + "\n" + sampleLogs
+	analysis, err := analyzeLogs("test", strings.NewReader(logs))
+	require.NoError(t, err)
+
+	assert.Equal(t, 15, analysis.Lines)
+	assert.Equal(t, 1, analysis.TooLong)
+	assert.Equal(t, 2, analysis.Unparsed)
+}
+
+func TestLogPatternsMatchMessage(t *testing.T) {
+	logs := `{"action":"lsm_compaction","level":"error","msg":"cannot read segment","error":"permission denied","time":"2024-05-01T10:01:00Z"}
+{"action":"replication","level":"error","msg":"request failed","error":"context deadline exceeded","time":"2024-05-01T10:02:00Z"}
+{"level":"error","msg":"replica 8cb4cbe4-6d7d-4a1c-9d3a-07b8c3b7d0e2 failed","error":"context deadline exceeded","time":"2024-05-01T10:03:00Z"}
+`
+	analysis, err := analyzeLogs("test", strings.NewReader(logs))
+	require.NoError(t, err)
+
+	// the action fields name compaction and replication, but only the message
+	// of the last line tells about a replica timing out
+	require.Len(t, analysis.Patterns, 1)
+	assert.Equal(t, "Replication timeout", analysis.Patterns[0].Name)
+	assert.Equal(t, 1, analysis.Patterns[0].Count)
+}
+
+func TestReadLogsDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "weaviate-0.log"), []byte(sampleLogs), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "weaviate-1.log"), []byte(sampleLogs), 0o644))
+
+	analysis, err := readLogs(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 14, analysis.Lines)
+	assert.Equal(t, 4, analysis.Clusters[0].Count)
+}
@@ -0,0 +1,123 @@
+package diagnostics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+// classlessModuleTypes are the module types which are not configured per
+// class and therefore never unused
+var classlessModuleTypes = map[string]bool{"backup": true, "offload": true, "usage": true}
+
+// ModuleUsage describes an enabled module or a module referenced by a class.
+type ModuleUsage struct {
+	Name string
+	// Type is the module type, e.g. text2vec, generative or reranker
+	Type    string
+	Enabled bool
+	// Details are the module details from /v1/meta, e.g. the model name
+	Details string
+	Classes []string
+}
+
+// Unused reports whether a module configured per class is enabled but not
+// used by any class.
+func (m ModuleUsage) Unused() bool {
+	return m.Enabled && len(m.Classes) == 0 && !classlessModuleTypes[m.Type]
+}
+
+// ClassList formats the classes using the module as comma separated list.
+func (m ModuleUsage) ClassList() string {
+	return strings.Join(m.Classes, ", ")
+}
+
+func moduleType(module string) string {
+	if i := strings.Index(module, "-"); i > 0 {
+		return module[:i]
+	}
+	return module
+}
+
+// moduleDetails formats the scalar settings Weaviate reports for a module.
+func moduleDetails(meta interface{}) string {
+	settings, ok := meta.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	var details []string
+	for key, value := range settings {
+		switch value.(type) {
+		case string, float64, bool:
+			details = append(details, fmt.Sprintf("%s: %v", key, value))
+		}
+	}
+	sort.Strings(details)
+	return strings.Join(details, ", ")
+}
+
+// classModules returns the modules a class uses as vectorizer of the class
+// or a named vector, or configures in its moduleConfig.
+func classModules(class *models.Class) []string {
+	modules := map[string]bool{}
+	for _, vectorizer := range classVectorizers(class) {
+		modules[vectorizer] = true
+	}
+	if moduleConfig, ok := class.ModuleConfig.(map[string]interface{}); ok {
+		for module := range moduleConfig {
+			modules[module] = true
+		}
+	}
+
+	var result []string
+	for module := range modules {
+		result = append(result, module)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// getModuleUsages cross-references the modules of /v1/meta with the classes.
+func getModuleUsages(modules map[string]interface{}, classes []*models.Class) []ModuleUsage {
+	usages := map[string]*ModuleUsage{}
+	for module, meta := range modules {
+		usages[module] = &ModuleUsage{Name: module, Type: moduleType(module), Enabled: true, Details: moduleDetails(meta)}
+	}
+
+	for _, class := range classes {
+		for _, module := range classModules(class) {
+			usage, ok := usages[module]
+			if !ok {
+				usage = &ModuleUsage{Name: module, Type: moduleType(module)}
+				usages[module] = usage
+			}
+			usage.Classes = append(usage.Classes, class.Class)
+		}
+	}
+
+	var result []ModuleUsage
+	for _, usage := range usages {
+		sort.Strings(usage.Classes)
+		result = append(result, *usage)
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].Name < result[b].Name
+	})
+	return result
+}
+
+func validateModules(usages []ModuleUsage) []Validation {
+	var validations []Validation
+	for _, usage := range usages {
+		if usage.Enabled {
+			continue
+		}
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("The %s module <code>%s</code> is not enabled, but used by the classes %s", usage.Type, usage.Name, usage.ClassList()),
+			Hint:    fmt.Sprintf("Requests which need the module fail. Add <code>%s</code> to <code>ENABLE_MODULES</code>", usage.Name),
+		})
+	}
+	return validations
+}
@@ -0,0 +1,53 @@
+package diagnostics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+func TestModuleUsages(t *testing.T) {
+	modules := map[string]interface{}{
+		"text2vec-openai":       map[string]interface{}{"documentationHref": "https://platform.openai.com", "name": "OpenAI Module"},
+		"generative-openai":     map[string]interface{}{},
+		"reranker-cohere":       map[string]interface{}{},
+		"backup-s3":             map[string]interface{}{},
+		"text2vec-transformers": map[string]interface{}{"model": map[string]interface{}{"name": "nested"}},
+	}
+	classes := []*models.Class{
+		{Class: "Article", Vectorizer: "text2vec-openai", ModuleConfig: map[string]interface{}{
+			"text2vec-openai":   map[string]interface{}{"model": "ada"},
+			"generative-cohere": map[string]interface{}{},
+		}},
+		{Class: "Product", VectorConfig: map[string]models.VectorConfig{
+			"title": {Vectorizer: map[string]interface{}{"text2vec-openai": map[string]interface{}{}}},
+			"image": {Vectorizer: map[string]interface{}{"multi2vec-clip": map[string]interface{}{}}},
+			// a vector brought by the user does not need a module
+			"custom": {Vectorizer: map[string]interface{}{"none": map[string]interface{}{}}},
+		}},
+	}
+
+	usages := getModuleUsages(modules, classes)
+	require.Len(t, usages, 7)
+	assert.Equal(t, ModuleUsage{Name: "backup-s3", Type: "backup", Enabled: true}, usages[0])
+	assert.False(t, usages[0].Unused())
+	assert.Equal(t, ModuleUsage{Name: "generative-cohere", Type: "generative", Classes: []string{"Article"}}, usages[1])
+	assert.True(t, usages[2].Unused(), usages[2].Name)
+	assert.Equal(t, "multi2vec-clip", usages[3].Name)
+	assert.Equal(t, ModuleUsage{Name: "text2vec-openai", Type: "text2vec", Enabled: true,
+		Details: "documentationHref: https://platform.openai.com, name: OpenAI Module", Classes: []string{"Article", "Product"}}, usages[5])
+	assert.Equal(t, "", usages[6].Details)
+
+	assert.Equal(t, []Validation{
+		{
+			Message: "The generative module <code>generative-cohere</code> is not enabled, but used by the classes Article",
+			Hint:    "Requests which need the module fail. Add <code>generative-cohere</code> to <code>ENABLE_MODULES</code>",
+		},
+		{
+			Message: "The multi2vec module <code>multi2vec-clip</code> is not enabled, but used by the classes Product",
+			Hint:    "Requests which need the module fail. Add <code>multi2vec-clip</code> to <code>ENABLE_MODULES</code>",
+		},
+	}, validateModules(usages))
+}
@@ -0,0 +1,350 @@
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/weaviate/weaviate-go-client/v4/weaviate"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate/filters"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate/graphql"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+const (
+	probeMeta       = "meta"
+	probeFetchByID  = "fetch by id"
+	probeWhere      = "where"
+	probeBM25       = "bm25"
+	probeNearVector = "nearVector"
+	probeHybrid     = "hybrid"
+
+	// probeLimit is the number of results the search queries ask for
+	probeLimit = 10
+	// slowQuery is the p95 latency from which on a query is flagged
+	slowQuery = time.Second
+	// slowMeta is the p95 latency of /v1/meta from which on the cluster
+	// itself is flagged as slow, the request does not touch any data
+	slowMeta = 200 * time.Millisecond
+)
+
+// ProbeResult holds the latencies of a query run repeatedly against a class.
+type ProbeResult struct {
+	// Class is empty for the meta baseline
+	Class    string
+	Query    string
+	Requests int
+	Errors   int
+	// Error is the first error, or why the query was skipped
+	Error   string
+	Skipped bool
+	P50     time.Duration
+	P95     time.Duration
+	P99     time.Duration
+}
+
+// probeTarget is what the queries of a class search for, taken from one of
+// its objects.
+type probeTarget struct {
+	// tenant is the tenant queries of a multi-tenant class go to
+	tenant       string
+	id           string
+	word         string
+	vector       []float32
+	targetVector string
+}
+
+type probeQuery struct {
+	name string
+	// skip explains why the query cannot run, if it cannot
+	skip string
+	run  func(ctx context.Context) error
+}
+
+// percentile returns the nearest-rank percentile of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// runProbe runs a query requests times with the given concurrency.
+func runProbe(class string, query probeQuery, requests int, concurrency int) ProbeResult {
+	result := ProbeResult{Class: class, Query: query.name}
+	if query.skip != "" {
+		result.Skipped, result.Error = true, query.skip
+		return result
+	}
+
+	var (
+		mu        sync.Mutex
+		durations []time.Duration
+		wg        sync.WaitGroup
+	)
+	jobs := make(chan struct{})
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				start := time.Now()
+				err := query.run(context.Background())
+				duration := time.Since(start)
+
+				mu.Lock()
+				result.Requests++
+				if err != nil {
+					result.Errors++
+					if result.Error == "" {
+						result.Error = err.Error()
+					}
+				} else {
+					durations = append(durations, duration)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < requests; i++ {
+		jobs <- struct{}{}
+	}
+	close(jobs)
+	wg.Wait()
+
+	sort.Slice(durations, func(a, b int) bool { return durations[a] < durations[b] })
+	result.P50 = percentile(durations, 50)
+	result.P95 = percentile(durations, 95)
+	result.P99 = percentile(durations, 99)
+	return result
+}
+
+// graphQLError returns the errors of a GraphQL response, which the client
+// does not treat as error.
+func graphQLError(resp *models.GraphQLResponse, err error) error {
+	if err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		var messages []string
+		for _, e := range resp.Errors {
+			messages = append(messages, e.Message)
+		}
+		return fmt.Errorf("%s", strings.Join(messages, "; "))
+	}
+	return nil
+}
+
+// getProbeTarget reads one object of the class to query for, of one of its
+// active tenants if the class is multi-tenant.
+func getProbeTarget(client *weaviate.Client, class *models.Class) (probeTarget, error) {
+	tenant, err := activeTenant(client, class)
+	if err != nil {
+		return probeTarget{}, err
+	}
+	objects, err := client.Data().ObjectsGetter().WithClassName(class.Class).WithTenant(tenant).WithVector().WithLimit(1).Do(context.Background())
+	if err != nil {
+		return probeTarget{}, err
+	}
+	if len(objects) == 0 {
+		if tenant != "" {
+			return probeTarget{}, fmt.Errorf("tenant %s of class %s has no objects", tenant, class.Class)
+		}
+		return probeTarget{}, fmt.Errorf("class %s has no objects", class.Class)
+	}
+
+	object := objects[0]
+	target := probeTarget{tenant: tenant, id: object.ID.String(), vector: object.Vector}
+	if len(target.vector) == 0 {
+		var names []string
+		for name := range object.Vectors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(names) > 0 {
+			target.vector, target.targetVector = object.Vectors[names[0]], names[0]
+		}
+	}
+
+	// the first word of a text property is searched for
+	values, _ := object.Properties.(map[string]interface{})
+	for _, property := range class.Properties {
+		if !isText(property) {
+			continue
+		}
+		if text, ok := values[property.Name].(string); ok && len(strings.Fields(text)) > 0 {
+			target.word = strings.Fields(text)[0]
+			break
+		}
+	}
+	return target, nil
+}
+
+func probeQueries(client *weaviate.Client, class string, target probeTarget) []probeQuery {
+	additional := graphql.Field{Name: "_additional", Fields: []graphql.Field{{Name: "id"}}}
+	get := func() *graphql.GetBuilder {
+		return client.GraphQL().Get().WithClassName(class).WithTenant(target.tenant).WithFields(additional).WithLimit(probeLimit)
+	}
+
+	queries := []probeQuery{
+		{name: probeFetchByID, run: func(ctx context.Context) error {
+			_, err := client.Data().ObjectsGetter().WithClassName(class).WithTenant(target.tenant).WithID(target.id).Do(ctx)
+			return err
+		}},
+		{name: probeWhere, run: func(ctx context.Context) error {
+			where := filters.Where().WithPath([]string{"id"}).WithOperator(filters.Equal).WithValueText(target.id)
+			return graphQLError(get().WithWhere(where).Do(ctx))
+		}},
+	}
+
+	bm25 := probeQuery{name: probeBM25, run: func(ctx context.Context) error {
+		return graphQLError(get().WithBM25(client.GraphQL().Bm25ArgBuilder().WithQuery(target.word)).Do(ctx))
+	}}
+	nearVector := probeQuery{name: probeNearVector, run: func(ctx context.Context) error {
+		nearVector := client.GraphQL().NearVectorArgBuilder().WithVector(target.vector)
+		if target.targetVector != "" {
+			nearVector = nearVector.WithTargetVectors(target.targetVector)
+		}
+		return graphQLError(get().WithNearVector(nearVector).Do(ctx))
+	}}
+	hybrid := probeQuery{name: probeHybrid, run: func(ctx context.Context) error {
+		hybrid := client.GraphQL().HybridArgumentBuilder().WithQuery(target.word).WithVector(target.vector)
+		if target.targetVector != "" {
+			hybrid = hybrid.WithTargetVectors(target.targetVector)
+		}
+		return graphQLError(get().WithHybrid(hybrid).Do(ctx))
+	}}
+	if target.word == "" {
+		bm25.skip = "no text to search for"
+		hybrid.skip = bm25.skip
+	}
+	if len(target.vector) == 0 {
+		nearVector.skip = "no vector to search with"
+		hybrid.skip = nearVector.skip
+	}
+	return append(queries, bm25, nearVector, hybrid)
+}
+
+// probe runs the meta baseline and the read queries against every class, or
+// the given ones. Multi-tenant classes are probed through one of their active
+// tenants.
+func probe(client *weaviate.Client, classes []*models.Class, only []string, requests int, concurrency int) []ProbeResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := []ProbeResult{runProbe("", probeQuery{name: probeMeta, run: func(ctx context.Context) error {
+		_, err := client.Misc().MetaGetter().Do(ctx)
+		return err
+	}}, requests, concurrency)}
+
+	for _, class := range classes {
+		if len(only) > 0 && !contains(only, class.Class) {
+			continue
+		}
+		target, err := getProbeTarget(client, class)
+		if err != nil {
+			results = append(results, ProbeResult{Class: class.Class, Query: probeFetchByID, Skipped: true, Error: err.Error()})
+			continue
+		}
+		for _, query := range probeQueries(client, class.Class, target) {
+			results = append(results, runProbe(class.Class, query, requests, concurrency))
+		}
+	}
+	return results
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func printProbeResults(w io.Writer, results []ProbeResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CLASS\tQUERY\tREQUESTS\tERRORS\tP50\tP95\tP99\tERROR")
+	for _, result := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%s\t%s\t%s\n", result.Class, result.Query, result.Requests, result.Errors,
+			result.P50.Round(time.Microsecond), result.P95.Round(time.Microsecond), result.P99.Round(time.Microsecond), result.Error)
+	}
+	tw.Flush()
+}
+
+func validateProbe(results []ProbeResult) []Validation {
+	var validations []Validation
+
+	var meta *ProbeResult
+	for i := range results {
+		if results[i].Query == probeMeta {
+			meta = &results[i]
+		}
+	}
+	slowCluster := meta != nil && meta.P95 > slowMeta
+	if slowCluster {
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("<code>/v1/meta</code> takes %s (p95), the cluster or the network to it is slow", meta.P95.Round(time.Millisecond)),
+			Hint:    "The request does not touch any data, check the CPU usage of the nodes, the load balancer and the network latency",
+		})
+	}
+
+	for _, result := range results {
+		if result.Errors > 0 {
+			queries := fmt.Sprintf("%s queries on class %s", result.Query, result.Class)
+			if result.Class == "" {
+				queries = "<code>/v1/meta</code> requests"
+			}
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("%d of %d %s failed: %s", result.Errors, result.Requests, queries, html.EscapeString(result.Error)),
+			})
+		}
+		if result.Query != probeMeta && !slowCluster && result.P95 > slowQuery {
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("%s queries on class %s take %s (p95)", result.Query, result.Class, result.P95.Round(time.Millisecond)),
+				Hint:    "Other requests are fast, so the queries themselves are slow. Check the vector index settings, compression and whether the index fits into memory",
+			})
+		}
+	}
+
+	return validations
+}
+
+// RunProbe runs the probe subcommand and prints the latencies.
+func RunProbe(out io.Writer) {
+	client := generateClient(globalConfig.Url, getAuthMethod())
+	schema, err := client.Schema().Getter().Do(context.Background())
+	if err != nil {
+		log.Fatal("Cannot retrieve Weaviate /v1/schema:", err)
+	}
+
+	results := probe(&client, schema.Classes, globalConfig.ProbeClasses, globalConfig.ProbeRequests, globalConfig.ProbeConcurrency)
+	printProbeResults(out, results)
+	validations := validateProbe(results)
+
+	if globalConfig.ProbeWrite {
+		nodes, err := client.Cluster().NodesStatusGetter().Do(context.Background())
+		if err != nil {
+			log.Fatal("Cannot retrieve Weaviate /v1/nodes:", err)
+		}
+		report := runWriteProbe(&client, nodes.Nodes, globalConfig.ProbeWriteObjects, globalConfig.ProbeWriteBatch)
+		fmt.Fprintln(out)
+		printWriteProbe(out, report)
+		validations = append(validations, validateWriteProbe(report)...)
+	}
+
+	printValidations(out, validations)
+}
@@ -0,0 +1,171 @@
+package diagnostics
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+const probeID = "00000000-0000-0000-0000-000000000001"
+
+// newStandInClient returns a client of a server which answers the requests of
+// the probe like a Weaviate with one object of class Article and none of class
+// Product. Hybrid queries fail.
+func newStandInClient(t *testing.T, queries map[string]int, mu *sync.Mutex) *weaviate.Client {
+	object := `{"class": "Article", "id": "` + probeID + `", "vector": [0.1, 0.2], "properties": {"title": "hello world"}}`
+	return newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/objects" && r.URL.Query().Get("class") == "Article":
+			w.Write([]byte(`{"objects": [` + object + `]}`))
+		case r.URL.Path == "/v1/objects":
+			w.Write([]byte(`{"objects": []}`))
+		case r.URL.Path == "/v1/objects/Article/"+probeID:
+			mu.Lock()
+			queries["fetch"]++
+			mu.Unlock()
+			w.Write([]byte(object))
+		case r.URL.Path == "/v1/graphql":
+			var body struct {
+				Query string `json:"query"`
+			}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			mu.Lock()
+			defer mu.Unlock()
+			for _, query := range []string{"where", "bm25", "nearVector", "hybrid"} {
+				if strings.Contains(body.Query, query+":") {
+					queries[query]++
+				}
+			}
+			if strings.Contains(body.Query, "hybrid:") {
+				w.Write([]byte(`{"errors": [{"message": "no vectorizer"}]}`))
+				return
+			}
+			w.Write([]byte(`{"data": {"Get": {"Article": [{"_additional": {"id": "` + probeID + `"}}]}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func TestProbe(t *testing.T) {
+	var mu sync.Mutex
+	queries := map[string]int{}
+	client := newStandInClient(t, queries, &mu)
+
+	classes := []*models.Class{
+		{Class: "Article", Properties: []*models.Property{{Name: "title", DataType: []string{"text"}}}},
+		{Class: "Product"},
+		{Class: "Ignored"},
+	}
+	results := probe(client, classes, []string{"Article", "Product"}, 6, 3)
+	require.Len(t, results, 7)
+
+	assert.Equal(t, probeMeta, results[0].Query)
+	assert.Equal(t, 6, results[0].Requests)
+	var names []string
+	for _, result := range results[1:5] {
+		names = append(names, result.Query)
+		assert.Equal(t, "Article", result.Class)
+		assert.Equal(t, 6, result.Requests, result.Query)
+		assert.Zero(t, result.Errors, result.Query)
+		assert.LessOrEqual(t, result.P50, result.P95)
+		assert.LessOrEqual(t, result.P95, result.P99)
+		assert.NotZero(t, result.P99, result.Query)
+	}
+	assert.Equal(t, []string{probeFetchByID, probeWhere, probeBM25, probeNearVector}, names)
+	assert.Equal(t, ProbeResult{Class: "Article", Query: probeHybrid, Requests: 6, Errors: 6, Error: "no vectorizer"}, results[5])
+	assert.Equal(t, ProbeResult{Class: "Product", Query: probeFetchByID, Skipped: true, Error: "class Product has no objects"}, results[6])
+	assert.Equal(t, map[string]int{"fetch": 6, "where": 6, "bm25": 6, "nearVector": 6, "hybrid": 6}, queries)
+
+	assert.Equal(t, []Validation{{Message: "6 of 6 hybrid queries on class Article failed: no vectorizer"}}, validateProbe(results))
+
+	var out bytes.Buffer
+	printProbeResults(&out, results)
+	assert.Contains(t, out.String(), "CLASS    QUERY")
+}
+
+func TestProbeMultiTenant(t *testing.T) {
+	object := `{"class": "Review", "id": "` + probeID + `", "vector": [0.1, 0.2], "properties": {"text": "good"}}`
+	var mu sync.Mutex
+	var withoutTenant []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		tenant := r.URL.Query().Get("tenant")
+		switch r.URL.Path {
+		case "/v1/schema/Review/tenants":
+			w.Write([]byte(`[{"name": "customerB", "activityStatus": "HOT"}, {"name": "customerA", "activityStatus": "COLD"}]`))
+			return
+		case "/v1/graphql":
+			var body struct {
+				Query string `json:"query"`
+			}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			if strings.Contains(body.Query, `tenant: "customerB"`) {
+				tenant = "customerB"
+			}
+			w.Write([]byte(`{"data": {"Get": {"Review": []}}}`))
+		case "/v1/objects":
+			w.Write([]byte(`{"objects": [` + object + `]}`))
+		default:
+			w.Write([]byte(object))
+		}
+		if tenant != "customerB" {
+			mu.Lock()
+			withoutTenant = append(withoutTenant, r.URL.Path)
+			mu.Unlock()
+		}
+	})
+
+	classes := []*models.Class{{Class: "Review", MultiTenancyConfig: &models.MultiTenancyConfig{Enabled: true},
+		Properties: []*models.Property{{Name: "text", DataType: []string{"text"}}}}}
+	results := probe(client, classes, nil, 2, 1)
+	require.Len(t, results, 6)
+	for _, result := range results {
+		assert.Zero(t, result.Errors, result.Query)
+	}
+	assert.Empty(t, withoutTenant)
+}
+
+func TestProbeQueriesSkipped(t *testing.T) {
+	queries := probeQueries(nil, "Article", probeTarget{id: probeID})
+	require.Len(t, queries, 5)
+	assert.Equal(t, "no text to search for", queries[2].skip)
+	assert.Equal(t, "no vector to search with", queries[3].skip)
+	assert.Equal(t, "no vector to search with", queries[4].skip)
+	assert.Equal(t, ProbeResult{Class: "Article", Query: probeBM25, Skipped: true, Error: "no text to search for"},
+		runProbe("Article", queries[2], 5, 2))
+}
+
+func TestValidateProbe(t *testing.T) {
+	results := []ProbeResult{
+		{Query: probeMeta, P95: time.Millisecond},
+		{Class: "Article", Query: probeNearVector, P95: 3 * time.Second},
+	}
+	assert.Equal(t, []Validation{{
+		Message: "nearVector queries on class Article take 3s (p95)",
+		Hint:    "Other requests are fast, so the queries themselves are slow. Check the vector index settings, compression and whether the index fits into memory",
+	}}, validateProbe(results))
+
+	// a slow cluster makes every query slow, only the cluster is flagged
+	results[0].P95 = time.Second
+	validations := validateProbe(results)
+	require.Len(t, validations, 1)
+	assert.Equal(t, "<code>/v1/meta</code> takes 1s (p95), the cluster or the network to it is slow", validations[0].Message)
+
+	// failed meta requests have no class, errors are rendered as HTML
+	assert.Equal(t, []Validation{{Message: "2 of 2 <code>/v1/meta</code> requests failed: unexpected &lt;html&gt; response"}},
+		validateProbe([]ProbeResult{{Query: probeMeta, Requests: 2, Errors: 2, Error: "unexpected <html> response"}}))
+
+	durations := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	assert.Equal(t, time.Duration(5), percentile(durations, 50))
+	assert.Equal(t, time.Duration(10), percentile(durations, 95))
+	assert.Zero(t, percentile(nil, 99))
+}
@@ -0,0 +1,190 @@
+package diagnostics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+// replicationConfig holds the replication settings of a class including the
+// ones the schema model of the vendored Weaviate version does not know.
+type replicationConfig struct {
+	Factor       int64 `json:"factor"`
+	AsyncEnabled bool  `json:"asyncEnabled"`
+}
+
+// asyncReplicationVersion is the first Weaviate version supporting async
+// replication
+const asyncReplicationVersion = "1.26.0"
+
+// Async replication metrics of Weaviate. A propagation happens whenever the
+// hash trees of two replicas differ.
+const (
+	metricHashTreeMismatches   = "async_replication_propagation_count"
+	metricRepairedObjects      = "async_replication_propagation_object_count"
+	metricPropagationFailures  = "async_replication_propagation_failure_count"
+	metricIterationFailures    = "async_replication_iteration_failure_count"
+	metricHashTreeInitFailures = "async_replication_hashtree_init_failure_count"
+)
+
+// ClassReplication describes the replication of a class.
+type ClassReplication struct {
+	Class        string
+	Factor       int64
+	AsyncEnabled bool
+	Shards       int
+	// UnderReplicated lists the shards with fewer replicas on healthy nodes
+	// than the replication factor, e.g. "abc123 (1/3)"
+	UnderReplicated []string
+}
+
+// MetricSample is the sum of all series of a Prometheus metric.
+type MetricSample struct {
+	Name  string
+	Value float64
+}
+
+// ReplicationReport describes the replication of all classes and the async
+// replication activity of the node serving the metrics endpoint.
+type ReplicationReport struct {
+	Classes   []ClassReplication
+	Nodes     int
+	DownNodes []string
+
+	MetricsReceived    bool
+	HashTreeMismatches float64
+	RepairedObjects    float64
+	RepairFailures     float64
+	// Metrics lists every replication related metric
+	Metrics []MetricSample
+}
+
+// LiveNodes is the number of healthy nodes.
+func (r ReplicationReport) LiveNodes() int {
+	return r.Nodes - len(r.DownNodes)
+}
+
+func getReplicationReport(classes []*models.Class, settings map[string]classSettings, nodes []*models.NodeStatus,
+	families map[string]*dto.MetricFamily,
+) *ReplicationReport {
+	report := &ReplicationReport{Nodes: len(nodes), MetricsReceived: len(families) > 0}
+
+	// replicas of every shard on healthy nodes
+	replicas := map[[2]string]int{}
+	for _, node := range nodes {
+		healthy := node.Status == nil || *node.Status == nodeStatusHealthy
+		if !healthy {
+			report.DownNodes = append(report.DownNodes, node.Name)
+		}
+		for _, shard := range node.Shards {
+			key := [2]string{shard.Class, shard.Name}
+			if _, ok := replicas[key]; !ok {
+				replicas[key] = 0
+			}
+			if healthy {
+				replicas[key]++
+			}
+		}
+	}
+	sort.Strings(report.DownNodes)
+
+	for _, class := range classes {
+		replication := ClassReplication{
+			Class:        class.Class,
+			Factor:       1,
+			AsyncEnabled: settings[class.Class].ReplicationConfig.AsyncEnabled,
+		}
+		if class.ReplicationConfig != nil && class.ReplicationConfig.Factor > 0 {
+			replication.Factor = class.ReplicationConfig.Factor
+		}
+
+		var shards []string
+		for key := range replicas {
+			if key[0] == class.Class {
+				shards = append(shards, key[1])
+			}
+		}
+		sort.Strings(shards)
+		replication.Shards = len(shards)
+		for _, shard := range shards {
+			if count := replicas[[2]string{class.Class, shard}]; int64(count) < replication.Factor {
+				replication.UnderReplicated = append(replication.UnderReplicated,
+					fmt.Sprintf("%s (%d/%d)", shard, count, replication.Factor))
+			}
+		}
+		report.Classes = append(report.Classes, replication)
+	}
+	sort.Slice(report.Classes, func(a, b int) bool {
+		return report.Classes[a].Class < report.Classes[b].Class
+	})
+
+	report.HashTreeMismatches = metricValue(families, metricHashTreeMismatches)
+	report.RepairedObjects = metricValue(families, metricRepairedObjects)
+	report.RepairFailures = metricValue(families, metricPropagationFailures) +
+		metricValue(families, metricIterationFailures) +
+		metricValue(families, metricHashTreeInitFailures)
+	for name := range families {
+		if strings.Contains(name, "replication") {
+			report.Metrics = append(report.Metrics, MetricSample{Name: name, Value: metricValue(families, name)})
+		}
+	}
+	sort.Slice(report.Metrics, func(a, b int) bool {
+		return report.Metrics[a].Name < report.Metrics[b].Name
+	})
+
+	return report
+}
+
+func validateReplication(report *ReplicationReport, version string) []Validation {
+	var validations []Validation
+	if report == nil {
+		return validations
+	}
+
+	downNodes := strings.Join(report.DownNodes, ", ")
+	for _, class := range report.Classes {
+		if len(report.DownNodes) > 0 && class.Factor > int64(report.LiveNodes()) && class.Factor <= int64(report.Nodes) {
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("Class %s has a replication factor of %d, but only %d of %d nodes are healthy",
+					class.Class, class.Factor, report.LiveNodes(), report.Nodes),
+				Hint: fmt.Sprintf("Requests with consistency level <code>ALL</code> fail and <code>QUORUM</code> needs %d healthy replicas. Bring back the nodes %s",
+					class.Factor/2+1, downNodes),
+			})
+		}
+
+		if class.Factor > 1 && !class.AsyncEnabled && version != "" && compareVersions(version, asyncReplicationVersion) >= 0 {
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("Class %s is replicated with factor %d, but async replication is disabled", class.Class, class.Factor),
+				Hint:    "Without async replication replicas which missed writes, e.g. during a restart, are only repaired when the objects are read. Set <code>replicationConfig.asyncEnabled: true</code>",
+			})
+		}
+
+		if len(class.UnderReplicated) > 0 {
+			listed := class.UnderReplicated
+			if len(listed) > maxListedShards {
+				listed = append(listed[:maxListedShards:maxListedShards], "...")
+			}
+			hint := "The shards are missing replicas although all nodes are healthy, check if nodes were removed from the cluster"
+			if len(report.DownNodes) > 0 {
+				hint = fmt.Sprintf("The missing replicas are likely on the nodes %s, which are down. Writes to these shards fail with consistency level <code>ALL</code>", downNodes)
+			}
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("%d shards of class %s have fewer than %d replicas on healthy nodes: %s",
+					len(class.UnderReplicated), class.Class, class.Factor, strings.Join(listed, ", ")),
+				Hint: hint,
+			})
+		}
+	}
+
+	if report.RepairFailures > 0 {
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("Async replication failed %.0f times on the node serving the metrics", report.RepairFailures),
+			Hint:    "Replicas stay inconsistent until async replication succeeds, check the logs for <code>async replication</code> errors",
+		})
+	}
+
+	return validations
+}
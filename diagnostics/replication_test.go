@@ -0,0 +1,66 @@
+package diagnostics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+func TestReplication(t *testing.T) {
+	healthy, down := "HEALTHY", "UNAVAILABLE"
+	shard := func(class string, name string) *models.NodeShardStatus {
+		return &models.NodeShardStatus{Class: class, Name: name}
+	}
+	nodes := []*models.NodeStatus{
+		{Name: "weaviate-0", Status: &healthy, Shards: []*models.NodeShardStatus{shard("Article", "a1"), shard("Article", "a2"), shard("Product", "p1")}},
+		{Name: "weaviate-1", Status: &healthy, Shards: []*models.NodeShardStatus{shard("Article", "a1")}},
+		{Name: "weaviate-2", Status: &down},
+	}
+	classes := []*models.Class{
+		{Class: "Product"},
+		{Class: "Article", ReplicationConfig: &models.ReplicationConfig{Factor: 3}},
+	}
+	families, err := parsePrometheusMetrics([]byte(`# TYPE async_replication_propagation_count counter
+async_replication_propagation_count{class_name="Article"} 4
+# TYPE async_replication_propagation_object_count counter
+async_replication_propagation_object_count{class_name="Article"} 17
+# TYPE async_replication_iteration_failure_count counter
+async_replication_iteration_failure_count 2
+# TYPE async_replication_iteration_duration_seconds histogram
+async_replication_iteration_duration_seconds_bucket{le="+Inf"} 9
+async_replication_iteration_duration_seconds_sum 1.5
+async_replication_iteration_duration_seconds_count 9
+# TYPE go_goroutines gauge
+go_goroutines 10
+`))
+	require.NoError(t, err)
+
+	report := getReplicationReport(classes, nil, nodes, families)
+	assert.Equal(t, []string{"weaviate-2"}, report.DownNodes)
+	assert.Equal(t, 2, report.LiveNodes())
+	assert.Equal(t, []ClassReplication{
+		{Class: "Article", Factor: 3, Shards: 2, UnderReplicated: []string{"a1 (2/3)", "a2 (1/3)"}},
+		{Class: "Product", Factor: 1, Shards: 1},
+	}, report.Classes)
+	assert.Equal(t, 4.0, report.HashTreeMismatches)
+	assert.Equal(t, 17.0, report.RepairedObjects)
+	assert.Equal(t, 2.0, report.RepairFailures)
+	assert.Len(t, report.Metrics, 4)
+	assert.Equal(t, MetricSample{Name: "async_replication_iteration_duration_seconds", Value: 9}, report.Metrics[0])
+
+	var messages []string
+	for _, validation := range validateReplication(report, "1.26.3") {
+		messages = append(messages, validation.Message)
+	}
+	assert.Equal(t, []string{
+		"Class Article has a replication factor of 3, but only 2 of 3 nodes are healthy",
+		"Class Article is replicated with factor 3, but async replication is disabled",
+		"2 shards of class Article have fewer than 3 replicas on healthy nodes: a1 (2/3), a2 (1/3)",
+		"Async replication failed 2 times on the node serving the metrics",
+	}, messages)
+
+	// async replication does not exist before 1.26
+	assert.Len(t, validateReplication(report, "1.25.0"), 3)
+}
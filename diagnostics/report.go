@@ -10,11 +10,14 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
 	"text/template"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/manifoldco/promptui"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/weaviate/weaviate-diagnostics/utilities"
 	"github.com/weaviate/weaviate-go-client/v4/weaviate"
 	"github.com/weaviate/weaviate-go-client/v4/weaviate/auth"
 	"github.com/weaviate/weaviate/entities/models"
@@ -30,8 +33,26 @@ type Report struct {
 	SchemaJSON        string
 	Modules           []string
 	ModulesJSON       string
+	ModuleUsages      []ModuleUsage
 	ProfileImg        string
-	HostInformation   HostInfo
+	CollectorHost     HostInfo
+	ServerHosts       []ServerHost
+	ServerRuntime     ServerRuntime
+	Shards            []ShardSummary
+	Tenants           []TenantSummary
+	Replication       *ReplicationReport
+	Backups           *BackupReport
+	Cluster           *ClusterReport
+	KnownIssues       []KnownIssue
+	Capacity          *CapacityReport
+	Samples           []ClassSample
+	Probe             []ProbeResult
+	WriteProbe        *WriteProbeReport
+	Agents            []AgentReport
+	Kube              *KubeReport
+	Docker            *DockerContainer
+	LogAnalysis       *LogAnalysis
+	DataDir           *DataDirUsage
 	PrometheusMetrics string
 	Validations       []Validation
 }
@@ -41,6 +62,18 @@ var globalConfig Config
 //go:embed templates/report.html
 var templateFile []byte
 
+// getAuthMethod returns the authentication method the flags ask for.
+func getAuthMethod() string {
+	authMethod := "none"
+	if globalConfig.User != "" {
+		authMethod = "oidc"
+	}
+	if globalConfig.ApiKey != "" {
+		authMethod = "apiKey"
+	}
+	return authMethod
+}
+
 func generateClient(clientUrl string, authMethod string) weaviate.Client {
 
 	var config weaviate.Config
@@ -126,6 +159,19 @@ func getInput(label string, mask rune) string {
 	return input
 }
 
+func renderReport(w io.Writer, report Report) error {
+	tmplt, err := template.New("report").Funcs(template.FuncMap{
+		"bytes": utilities.FormatBytes,
+		// Prometheus metrics are floats
+		"metricBytes": func(size float64) string { return utilities.FormatBytes(int64(size)) },
+	}).Parse(string(templateFile))
+	if err != nil {
+		return err
+	}
+
+	return tmplt.Execute(w, report)
+}
+
 func GenerateReport() {
 
 	cyan := color.New(color.FgCyan).SprintFunc()
@@ -139,16 +185,33 @@ func GenerateReport() {
   |__/_/ 	   
 `)
 
-	fmt.Printf("- Retrieving Weaviate schema from: %s\n", cyan(globalConfig.Url))
-
-	authMethod := "none"
-	if globalConfig.User != "" {
-		authMethod = "oidc"
+	var kube *KubeReport
+	if globalConfig.Kube {
+		fmt.Printf("- Collecting StatefulSet %s from Kubernetes\n", cyan(globalConfig.StatefulSet))
+		kubeReport, stopForwarding, err := setupKube()
+		if err != nil {
+			log.Fatal("Cannot collect Kubernetes resources: ", err)
+		}
+		defer stopForwarding()
+		kube = kubeReport
+		fmt.Printf("%s %d pods, %d volume claims and %d events retrieved from namespace %s\n",
+			green("✓"), len(kube.Pods), len(kube.PVCs), len(kube.Events), kube.Namespace)
 	}
-	if globalConfig.ApiKey != "" {
-		authMethod = "apiKey"
+
+	var docker *DockerContainer
+	if globalConfig.Docker != "" {
+		container, err := getDockerContainer(globalConfig.DockerHost, globalConfig.Docker, globalConfig.LogLines)
+		if err != nil {
+			log.Fatal("Cannot collect Docker container: ", err)
+		}
+		docker = container
+		fmt.Printf("%s Docker container %s retrieved\n", green("✓"), docker.Name)
 	}
 
+	fmt.Printf("- Retrieving Weaviate schema from: %s\n", cyan(globalConfig.Url))
+
+	authMethod := getAuthMethod()
+
 	fmt.Printf("- Authentication: %s\n", cyan(authMethod))
 
 	client := generateClient(globalConfig.Url, authMethod)
@@ -182,6 +245,7 @@ func GenerateReport() {
 	for k := range modules {
 		moduleList = append(moduleList, k)
 	}
+	sort.Strings(moduleList)
 
 	modulesJSON, err := json.Marshal(meta.Modules)
 	if err != nil {
@@ -193,7 +257,7 @@ func GenerateReport() {
 		log.Fatal("Cannot parse Weaviate schema:", err)
 	}
 
-	nodes, err := client.Cluster().NodesStatusGetter().Do(context.Background())
+	nodes, err := client.Cluster().NodesStatusGetter().WithOutput("verbose").Do(context.Background())
 	if err != nil {
 		log.Fatal("Cannot retrieve Weaviate /v1/nodes:", err)
 	}
@@ -212,12 +276,78 @@ func GenerateReport() {
 
 	}
 
+	// the schema model does not know the auto tenant and async replication
+	// settings yet, they are read from the raw schema if possible
+	var settings map[string]classSettings
+	if authMethod == "oidc" {
+		fmt.Printf("%s Skipping auto tenant and async replication checks, they require API key authentication\n", yellow("!"))
+	} else {
+		settings, err = getClassSettings(globalConfig.Url, globalConfig.ApiKey)
+		if err != nil {
+			fmt.Printf("%s Skipping auto tenant and async replication checks: %s\n", red("x"), err)
+		}
+	}
+
+	var tenants []TenantSummary
+	for _, class := range schema.Classes {
+		if class.MultiTenancyConfig == nil || !class.MultiTenancyConfig.Enabled {
+			continue
+		}
+		classTenants, err := client.Schema().TenantsGetter().WithClassName(class.Class).Do(context.Background())
+		if err != nil {
+			fmt.Printf("%s Cannot retrieve tenants of class %s: %s\n", red("x"), class.Class, err)
+			continue
+		}
+		tenants = append(tenants, getTenantSummary(class.Class, classTenants, settings[class.Class].MultiTenancyConfig, nodes.Nodes))
+	}
+	if len(tenants) > 0 {
+		fmt.Printf("%s Tenants of %d classes retrieved\n", green("✓"), len(tenants))
+	}
+
+	moduleUsages := getModuleUsages(modules, schema.Classes)
+
+	var probeResults []ProbeResult
+	if globalConfig.Probe {
+		fmt.Printf("- Probing query latencies..\n")
+		probeResults = probe(&client, schema.Classes, globalConfig.ProbeClasses, globalConfig.ProbeRequests, globalConfig.ProbeConcurrency)
+		fmt.Printf("%s %d queries probed\n", green("✓"), len(probeResults))
+	}
+
+	// the write probe creates and deletes a class, so it only runs if asked for
+	var writeProbeReport *WriteProbeReport
+	if globalConfig.ProbeWrite {
+		writeProbeReport = runWriteProbe(&client, nodes.Nodes, globalConfig.ProbeWriteObjects, globalConfig.ProbeWriteBatch)
+		fmt.Printf("%s Writes probed with class %s\n", green("✓"), writeProbeReport.Class)
+	}
+
+	// object data is only read if asked for
+	var samples []ClassSample
+	if globalConfig.SampleObjects > 0 {
+		samples = getObjectSamples(&client, schema.Classes, globalConfig.SampleObjects, globalConfig.SampleVectors)
+		fmt.Printf("%s Objects of %d classes sampled\n", green("✓"), len(samples))
+	}
+
+	backups := getBackupReport(&client, getBackupBackends(modules), globalConfig.Backups)
+	if len(backups.Backups) > 0 {
+		fmt.Printf("%s Status of %d backups retrieved\n", green("✓"), len(backups.Backups))
+	}
+
 	var prometheusMetrics []byte = []byte{}
+	serverRuntime := ServerRuntime{Source: globalConfig.MetricsUrl}
+	var families map[string]*dto.MetricFamily
 	resp, err := http.Get(globalConfig.MetricsUrl)
 	if err != nil {
 		fmt.Printf("%s Skipping prometheus metrics: %s\n", red("x"), err)
 	} else {
 		prometheusMetrics, err = io.ReadAll(resp.Body)
+		if err == nil {
+			parsed, err := parsePrometheusMetrics(prometheusMetrics)
+			if err != nil {
+				fmt.Printf("%s Cannot parse prometheus metrics: %s\n", red("x"), err)
+			}
+			families = parsed
+			serverRuntime = getServerRuntime(globalConfig.MetricsUrl, families)
+		}
 		// limit the amount of metrics to 100k bytes
 		if len(prometheusMetrics) > 500000 {
 			prometheusMetrics = prometheusMetrics[:500000]
@@ -230,16 +360,100 @@ func GenerateReport() {
 		defer resp.Body.Close()
 	}
 
-	hostInformation := getHostInfo()
-	fmt.Printf("%s Host data retrieved\n", green("✓"))
+	replication := getReplicationReport(schema.Classes, settings, nodes.Nodes, families)
+
+	// only Weaviate 1.25 and newer use RAFT
+	var cluster *ClusterReport
+	if compareVersions(meta.Version, raftVersion) >= 0 {
+		if authMethod == "oidc" {
+			fmt.Printf("%s Skipping RAFT cluster statistics, they require API key authentication\n", yellow("!"))
+		} else if statistics, err := getClusterStatistics(globalConfig.Url, globalConfig.ApiKey); err != nil {
+			fmt.Printf("%s Skipping RAFT cluster statistics: %s\n", red("x"), err)
+		} else {
+			cluster = getClusterReport(statistics, families)
+			fmt.Printf("%s RAFT cluster statistics of %d nodes retrieved\n", green("✓"), len(cluster.Nodes))
+		}
+	}
+
+	collectorHost := getHostInfo(globalConfig.DataPath)
+	fmt.Printf("%s Collector host data retrieved\n", green("✓"))
+	if !collectorHost.LocalWeaviate {
+		fmt.Printf("%s No local Weaviate process found, collector host data does not describe the Weaviate server\n", yellow("!"))
+	}
 
-	tmplt := template.Must(template.New("report").Parse(string(templateFile)))
+	dataDir, err := getDataDirUsage(globalConfig.DataPath)
+	if err != nil {
+		fmt.Printf("%s Skipping data directory analysis: %s\n", red("x"), err)
+	} else {
+		fmt.Printf("%s Data directory analyzed\n", green("✓"))
+	}
+
+	var logAnalysis *LogAnalysis
+	if globalConfig.Logs != "" {
+		logAnalysis, err = readLogs(globalConfig.Logs)
+		if err != nil {
+			fmt.Printf("%s Skipping log analysis: %s\n", red("x"), err)
+		} else {
+			fmt.Printf("%s %d log lines analyzed\n", green("✓"), logAnalysis.Lines)
+		}
+	}
 
 	fmt.Printf("- Generating CPU profile..\n")
 	profile := getProf(globalConfig.ProfileUrl)
 	fmt.Printf("%s CPU profile retrieved\n", green("✓"))
 
-	validations := validate(schema)
+	serverHosts := getServerHosts(nodes.Nodes)
+	var agents []AgentReport
+	if len(globalConfig.Agents) > 0 {
+		agents = queryAgents(agentURLs(globalConfig.Agents, serverHosts), globalConfig.AgentToken, globalConfig.LogLines, serverHosts)
+		for _, agent := range agents {
+			if agent.Error != "" {
+				fmt.Printf("%s Agent %s failed: %s\n", red("x"), agent.URL, agent.Error)
+			} else {
+				fmt.Printf("%s Agent data of node %s retrieved\n", green("✓"), agent.Info.Node)
+			}
+		}
+	}
+
+	var localEnv map[string]string
+	if collectorHost.LocalWeaviate {
+		localEnv = localWeaviateEnv()
+	}
+	getenv := serverGetenv(serverHosts, docker, kube, localEnv)
+	if getenv == nil && len(agents) == 0 {
+		fmt.Printf("%s Skipping environment checks, the environment of Weaviate is only known with --docker, --kube, --agents or on its host\n", yellow("!"))
+	}
+
+	capacity := getCapacityReport(schema.Classes, nodes.Nodes, sampledDimensions(samples),
+		getNodeMemory(serverHosts, kube, docker, collectorHost, serverRuntime, localEnv))
+	fmt.Printf("%s Memory of %d vector indexes estimated\n", green("✓"), len(capacity.Indexes))
+	if len(capacity.MissingDimensions) > 0 && !globalConfig.SampleVectors {
+		fmt.Printf("%s Dimensions of %d vector indexes unknown, pass --sample-objects 1 --sample-vectors to read them\n",
+			yellow("!"), len(capacity.MissingDimensions))
+	}
+
+	issues, err := loadKnownIssues(knownIssuesFile)
+	if err != nil {
+		log.Fatal("Cannot parse the version limitations: ", err)
+	}
+	knownIssues := matchKnownIssues(issues, clusterVersions(meta.Version, serverHosts), usedFeatures(schema.Classes, len(nodes.Nodes)))
+
+	validations := validate(schema, collectorHost, getenv, meta.Version, serverHosts)
+	validations = append(validations, validateShards(nodes.Nodes)...)
+	validations = append(validations, validateTenants(tenants, meta.Version, globalConfig.MaxActiveTenants)...)
+	validations = append(validations, validateMultiTenancyConfigs(settings)...)
+	validations = append(validations, validateReplication(replication, meta.Version)...)
+	validations = append(validations, validateBackups(backups)...)
+	validations = append(validations, validateCluster(cluster, serverHosts)...)
+	validations = append(validations, validateKnownIssues(knownIssues)...)
+	validations = append(validations, validateModules(moduleUsages)...)
+	validations = append(validations, validateCapacity(capacity)...)
+	validations = append(validations, validateObjectSamples(schema.Classes, samples)...)
+	validations = append(validations, validateProbe(probeResults)...)
+	validations = append(validations, validateWriteProbe(writeProbeReport)...)
+	validations = append(validations, validateKube(kube)...)
+	validations = append(validations, validateDocker(docker)...)
+	validations = append(validations, validateLogs(logAnalysis)...)
 	fmt.Printf("%s Running validation checks\n", green("✓"))
 
 	report := Report{
@@ -252,8 +466,26 @@ func GenerateReport() {
 		SchemaJSON:        string(schemaJSON),
 		Modules:           moduleList,
 		ModulesJSON:       string(modulesJSON),
+		ModuleUsages:      moduleUsages,
 		ProfileImg:        profile,
-		HostInformation:   hostInformation,
+		CollectorHost:     collectorHost,
+		ServerHosts:       serverHosts,
+		ServerRuntime:     serverRuntime,
+		Shards:            getShardSummaries(nodes.Nodes),
+		Tenants:           tenants,
+		Replication:       replication,
+		Backups:           backups,
+		Cluster:           cluster,
+		KnownIssues:       knownIssues,
+		Capacity:          capacity,
+		Samples:           samples,
+		Probe:             probeResults,
+		WriteProbe:        writeProbeReport,
+		Agents:            agents,
+		Kube:              kube,
+		Docker:            docker,
+		LogAnalysis:       logAnalysis,
+		DataDir:           dataDir,
 		PrometheusMetrics: string(prometheusMetrics),
 		Validations:       validations,
 	}
@@ -262,7 +494,7 @@ func GenerateReport() {
 	if err != nil {
 		log.Fatal("Cannot create report file:", err)
 	}
-	err = tmplt.Execute(outputFile, report)
+	err = renderReport(outputFile, report)
 	if err != nil {
 		log.Fatal("Cannot write report file:", err)
 	}
@@ -0,0 +1,219 @@
+package diagnostics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+// newTestClient returns a client of a test server which answers /v1/meta,
+// which the client reads when it is created, and passes every other request
+// to handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *weaviate.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/meta" {
+			w.Write([]byte(`{"version": "1.26.0"}`))
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := weaviate.NewClient(weaviate.Config{Host: strings.TrimPrefix(server.URL, "http://"), Scheme: "http"})
+	require.NoError(t, err)
+	return client
+}
+
+func TestRenderReport(t *testing.T) {
+	tests := []struct {
+		name     string
+		report   Report
+		contains []string
+	}{
+		{
+			name: "data directory",
+			report: Report{
+				Meta: &models.Meta{Version: "1.24.10"},
+				DataDir: &DataDirUsage{
+					Path:  "/var/lib/weaviate",
+					Total: 2048,
+					Entries: []DataDirEntry{
+						{Class: "article", Shard: "abc", Component: componentObjects, Bytes: 2048, Files: 2},
+					},
+				},
+			},
+			contains: []string{"1.24.10", `<td class="text-end" data-value="2048">2.0 KiB</td>`},
+		},
+		{
+			name:     "server runtime",
+			report:   Report{ServerRuntime: ServerRuntime{MetricsReceived: true, ResidentBytes: 2 << 20}},
+			contains: []string{"2.0 MiB"},
+		},
+		{
+			name:     "server hosts",
+			report:   Report{ServerHosts: []ServerHost{{Name: "weaviate-0"}}},
+			contains: []string{"weaviate-0", "No Weaviate process was found on it"},
+		},
+		{
+			name: "agents",
+			report: Report{Agents: []AgentReport{
+				{URL: "http://weaviate-0:7070", Info: &AgentInfo{Node: "weaviate-0"}, Logs: "<last line>"},
+			}},
+			contains: []string{"&lt;last line&gt;"},
+		},
+		{
+			name: "agent data directory error",
+			report: Report{ServerHosts: []ServerHost{
+				{Name: "weaviate-0", Agent: &AgentInfo{DataDirError: "open <data>: permission denied"}},
+			}},
+			contains: []string{"open &lt;data&gt;: permission denied"},
+		},
+		{
+			name: "kubernetes",
+			report: Report{Kube: &KubeReport{Pods: []KubePod{{
+				Name:       "weaviate-0",
+				Containers: []KubeContainer{{Name: "weaviate", Image: "cr.weaviate.io/semitechnologies/weaviate:1.24.10", Env: []KubeEnvVar{{Name: "OPENAI_APIKEY", Value: "<from secret openai/key>"}}}},
+				Volumes:    []string{"db/weaviate"},
+				Runtime:    ServerRuntime{MetricsReceived: true, ResidentBytes: 2 << 20},
+			}}}},
+			contains: []string{"db/weaviate", "2.0 MiB", "&lt;from secret openai/key&gt;"},
+		},
+		{
+			name: "kubernetes port forward error",
+			report: Report{Kube: &KubeReport{Pods: []KubePod{{
+				Name:         "weaviate-0",
+				Containers:   []KubeContainer{{Name: "weaviate"}},
+				ForwardError: "pod <weaviate-0> is not running",
+			}}}},
+			contains: []string{"pod &lt;weaviate-0&gt; is not running"},
+		},
+		{
+			name: "docker",
+			report: Report{Docker: &DockerContainer{
+				Name:  "weaviate",
+				Image: "cr.weaviate.io/semitechnologies/weaviate:1.24.10",
+			}},
+			contains: []string{"cr.weaviate.io/semitechnologies/weaviate:1.24.10"},
+		},
+		{
+			name: "logs",
+			report: Report{LogAnalysis: &LogAnalysis{
+				Source:  "weaviate.log",
+				TooLong: 1,
+				Clusters: []LogCluster{
+					{Level: "error", Template: "compaction failed: open <path>: no such file", Count: 2},
+				},
+			}},
+			contains: []string{"compaction failed: open &lt;path&gt;: no such file", "1 were longer than 1 MiB and skipped"},
+		},
+		{
+			name: "shards",
+			report: Report{Shards: []ShardSummary{
+				{Class: "Article", Node: "weaviate-0", Shards: 3, Statuses: map[string]int{"READY": 1, "READONLY": 1, "INDEXING": 1}},
+			}},
+			contains: []string{"INDEXING 1, READONLY 1, READY 1"},
+		},
+		{
+			name: "tenants",
+			report: Report{Tenants: []TenantSummary{
+				{Class: "Article", Tenants: 4, Statuses: map[string]int{"HOT": 3, "COLD": 1}},
+			}},
+			contains: []string{"COLD 1, HOT 3"},
+		},
+		{
+			name: "replication",
+			report: Report{Replication: &ReplicationReport{
+				Classes:   []ClassReplication{{Class: "Article", Factor: 3, Shards: 1}},
+				Nodes:     3,
+				DownNodes: []string{"weaviate-2"},
+			}},
+			contains: []string{"2 of 3 nodes healthy, down: weaviate-2"},
+		},
+		{
+			name: "backups",
+			report: Report{Backups: &BackupReport{
+				Backends: []string{"s3"},
+				Backups:  []BackupStatus{{ID: "nightly", Backend: "s3", Operation: "create", Status: "SUCCESS", Path: "s3://backups/nightly"}},
+			}},
+			contains: []string{"s3://backups/nightly"},
+		},
+		{
+			name: "cluster",
+			report: Report{Cluster: &ClusterReport{
+				Nodes: []RaftNode{{Name: "weaviate-0", LeaderID: "weaviate-0", LeaderAddress: "10.0.0.1:8300"}},
+			}},
+			contains: []string{"weaviate-0 (10.0.0.1:8300)"},
+		},
+		{
+			name: "version limitations",
+			report: Report{KnownIssues: []KnownIssue{
+				{ID: "compaction", Versions: "< 1.24.5", Title: "Compaction bug", Affected: []string{"1.24.1"}},
+			}},
+			contains: []string{"Version Limitations", "Compaction bug"},
+		},
+		{
+			name: "modules",
+			report: Report{ModuleUsages: []ModuleUsage{
+				{Name: "text2vec-openai", Type: "text2vec", Enabled: true, Classes: []string{"Article", "Product"}},
+			}},
+			contains: []string{"Article, Product"},
+		},
+		{
+			name: "capacity",
+			report: Report{Capacity: &CapacityReport{
+				Indexes:           []VectorIndexEstimate{{Class: "Article", IndexType: "hnsw", Dimensions: 1536, VectorBytes: 12 << 20, GraphBytes: 2 << 20}},
+				MissingDimensions: []string{"Product"},
+			}},
+			contains: []string{"14.0 MiB", "--sample-objects 1 --sample-vectors"},
+		},
+		{
+			name: "object samples",
+			report: Report{Samples: []ClassSample{{
+				Class:      "Article",
+				Objects:    10,
+				Properties: []PropertySample{{Name: "title", Filled: 10, TextValues: 10, TextLength: 80}},
+				Dimensions: map[string]map[int]int{"": {2: 1, 3: 9}, "title": {2: 10}},
+			}}},
+			contains: []string{"default: 2 (1), 3 (9); title: 2"},
+		},
+		{
+			name: "probe",
+			report: Report{Probe: []ProbeResult{
+				{Class: "Article", Query: "hybrid", Requests: 6, Errors: 6, Error: "no vectorizer"},
+			}},
+			contains: []string{"no vectorizer"},
+		},
+		{
+			name: "write probe",
+			report: Report{WriteProbe: &WriteProbeReport{
+				Class:    writeProbeClassPrefix + "1700000000",
+				Replicas: 2,
+				Results: []WriteProbeResult{
+					{ConsistencyLevel: "ALL", Batches: 3, Objects: 25, Errors: 3, Error: "cannot achieve consistency level ALL", P50: time.Millisecond},
+				},
+			}},
+			contains: []string{"cannot achieve consistency level ALL"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.report.Meta == nil {
+				test.report.Meta = &models.Meta{}
+			}
+			var out bytes.Buffer
+			require.NoError(t, renderReport(&out, test.report))
+			for _, expected := range test.contains {
+				assert.Contains(t, out.String(), expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/weaviate/weaviate-go-client/v4/weaviate"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+const (
+	// maxSampleObjects bounds the objects read per class
+	maxSampleObjects = 100
+	// minSampleObjects is the sample size from which on fill rates are
+	// meaningful enough to flag properties
+	minSampleObjects = 10
+	// maxFilterableTextLength is the average text length from which on a
+	// filterable index is flagged
+	maxFilterableTextLength = 1000
+)
+
+// PropertySample holds the statistics of a property in the sampled objects.
+// Values are never kept, only counted and measured.
+type PropertySample struct {
+	Name   string
+	Filled int
+	// TextValues and TextLength count the text values and their characters
+	TextValues int
+	TextLength int
+}
+
+// ClassSample holds the statistics of the sampled objects of a class.
+type ClassSample struct {
+	Class string
+	// Tenant is the tenant the objects of a multi-tenant class are read from
+	Tenant     string
+	Objects    int
+	Properties []PropertySample
+	// Dimensions counts the vectors by target vector and dimensions, the
+	// class vector has the target ""
+	Dimensions map[string]map[int]int
+	Error      string
+}
+
+// FillRate is the share of sampled objects with the property set in percent.
+func (s ClassSample) FillRate(property PropertySample) float64 {
+	if s.Objects == 0 {
+		return 0
+	}
+	return float64(property.Filled) * 100 / float64(s.Objects)
+}
+
+// AverageTextLength is the average number of characters of the text values.
+func (p PropertySample) AverageTextLength() float64 {
+	if p.TextValues == 0 {
+		return 0
+	}
+	return float64(p.TextLength) / float64(p.TextValues)
+}
+
+// DimensionList formats the dimensions of the target vectors as e.g.
+// "default: 1536, title: 384 (2), 768 (1)".
+func (s ClassSample) DimensionList() string {
+	var targets []string
+	for target, counts := range s.Dimensions {
+		var dimensions []string
+		for dimension, count := range counts {
+			if len(counts) > 1 {
+				dimensions = append(dimensions, fmt.Sprintf("%d (%d)", dimension, count))
+			} else {
+				dimensions = append(dimensions, fmt.Sprint(dimension))
+			}
+		}
+		sort.Strings(dimensions)
+		targets = append(targets, fmt.Sprintf("%s: %s", targetName(target), strings.Join(dimensions, ", ")))
+	}
+	sort.Strings(targets)
+	return strings.Join(targets, "; ")
+}
+
+func targetName(target string) string {
+	if target == "" {
+		return "default"
+	}
+	return target
+}
+
+// countText adds the characters of text and text array values.
+func countText(property *PropertySample, value interface{}) {
+	switch value := value.(type) {
+	case string:
+		property.TextValues++
+		property.TextLength += len([]rune(value))
+	case []interface{}:
+		for _, item := range value {
+			if text, ok := item.(string); ok {
+				property.TextValues++
+				property.TextLength += len([]rune(text))
+			}
+		}
+	}
+}
+
+func isFilled(value interface{}) bool {
+	switch value := value.(type) {
+	case nil:
+		return false
+	case string:
+		return value != ""
+	case []interface{}:
+		return len(value) > 0
+	}
+	return true
+}
+
+func sampleClass(class *models.Class, objects []*models.Object) ClassSample {
+	sample := ClassSample{Class: class.Class, Objects: len(objects), Dimensions: map[string]map[int]int{}}
+
+	properties := make([]PropertySample, len(class.Properties))
+	for i, property := range class.Properties {
+		properties[i].Name = property.Name
+	}
+	for _, object := range objects {
+		values, _ := object.Properties.(map[string]interface{})
+		for i := range properties {
+			value := values[properties[i].Name]
+			if isFilled(value) {
+				properties[i].Filled++
+			}
+			countText(&properties[i], value)
+		}
+
+		addDimensions := func(target string, dimensions int) {
+			if sample.Dimensions[target] == nil {
+				sample.Dimensions[target] = map[int]int{}
+			}
+			sample.Dimensions[target][dimensions]++
+		}
+		if len(object.Vector) > 0 {
+			addDimensions("", len(object.Vector))
+		}
+		for target, vector := range object.Vectors {
+			addDimensions(target, len(vector))
+		}
+	}
+	sample.Properties = properties
+	return sample
+}
+
+// getObjectSamples reads up to size objects of every class, with their
+// vectors if withVectors is set. Multi-tenant classes are sampled through one
+// of their active tenants.
+func getObjectSamples(client *weaviate.Client, classes []*models.Class, size int, withVectors bool) []ClassSample {
+	if size > maxSampleObjects {
+		size = maxSampleObjects
+	}
+
+	var samples []ClassSample
+	for _, class := range classes {
+		tenant, err := activeTenant(client, class)
+		if err != nil {
+			samples = append(samples, ClassSample{Class: class.Class, Error: err.Error()})
+			continue
+		}
+		getter := client.Data().ObjectsGetter().WithClassName(class.Class).WithTenant(tenant).WithLimit(size)
+		if withVectors {
+			getter = getter.WithVector()
+		}
+		objects, err := getter.Do(context.Background())
+		if err != nil {
+			samples = append(samples, ClassSample{Class: class.Class, Tenant: tenant, Error: err.Error()})
+			continue
+		}
+		sample := sampleClass(class, objects)
+		sample.Tenant = tenant
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+// sampledDimensions returns the most common dimensions of every target vector
+// by indexKey.
+func sampledDimensions(samples []ClassSample) map[string]int {
+	dimensions := map[string]int{}
+	for _, sample := range samples {
+		for target, counts := range sample.Dimensions {
+			common, max := 0, 0
+			for dimension, count := range counts {
+				if count > max || (count == max && dimension > common) {
+					common, max = dimension, count
+				}
+			}
+			dimensions[indexKey(sample.Class, target)] = common
+		}
+	}
+	return dimensions
+}
+
+func validateObjectSamples(classes []*models.Class, samples []ClassSample) []Validation {
+	var validations []Validation
+
+	byName := map[string]*models.Class{}
+	for _, class := range classes {
+		byName[class.Class] = class
+	}
+
+	for _, sample := range samples {
+		class := byName[sample.Class]
+		if class == nil || sample.Error != "" {
+			continue
+		}
+
+		var targets []string
+		for target := range sample.Dimensions {
+			targets = append(targets, target)
+		}
+		sort.Strings(targets)
+		for _, target := range targets {
+			if len(sample.Dimensions[target]) > 1 {
+				validations = append(validations, Validation{
+					Message: fmt.Sprintf("The sampled objects of class %s have %s vectors of different dimensions: %s",
+						sample.Class, targetName(target), sample.DimensionList()),
+					Hint: "Vectors of one index must have the same dimensions, objects with other dimensions fail to import. Check the vectorizer or model used by the clients which bring their own vectors",
+				})
+			}
+		}
+
+		for _, index := range classVectorIndexes(class) {
+			configured := schemaDimensions(class, index)
+			for dimensions := range sample.Dimensions[index.vector] {
+				if configured > 0 && dimensions != configured {
+					validations = append(validations, Validation{
+						Message: fmt.Sprintf("Class %s configures %d dimensions for the %s vector, but sampled objects have %d",
+							sample.Class, configured, targetName(index.vector), dimensions),
+						Hint: "The vectorizer settings changed after the data was imported, or clients bring their own vectors of another model",
+					})
+				}
+			}
+		}
+
+		if sample.Objects < minSampleObjects {
+			continue
+		}
+		for i, property := range sample.Properties {
+			if property.Filled == 0 {
+				validations = append(validations, Validation{
+					Message: fmt.Sprintf("Property %s of class %s is not set on any of %d sampled objects", property.Name, sample.Class, sample.Objects),
+					Hint:    "Every property has its own buckets on every shard. Disable the indexes of properties which are not used",
+				})
+				continue
+			}
+			if property.AverageTextLength() > maxFilterableTextLength && enabled(class.Properties[i].IndexFilterable) {
+				validations = append(validations, Validation{
+					Message: fmt.Sprintf("Property %s of class %s holds %.0f characters on average and is filterable",
+						property.Name, sample.Class, property.AverageTextLength()),
+					Hint: "A filterable index on long texts is large and rarely useful. Set <code>indexFilterable: false</code> if you only search the property",
+				})
+			}
+		}
+	}
+
+	return validations
+}
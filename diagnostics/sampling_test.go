@@ -0,0 +1,96 @@
+package diagnostics
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+func TestObjectSamples(t *testing.T) {
+	var objects []string
+	for i := 0; i < 10; i++ {
+		dimensions := "[0.1, 0.2, 0.3]"
+		if i == 9 {
+			dimensions = "[0.1, 0.2]"
+		}
+		objects = append(objects, fmt.Sprintf(`{"class": "Article", "vector": %s, "vectors": {"title": [1, 2]},
+			"properties": {"title": "Weaviate", "body": "%s", "tags": ["a", "bc"], "empty": ""}}`, dimensions, strings.Repeat("x", 2000)))
+	}
+
+	var include, limit string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/objects" && r.URL.Query().Get("class") == "Article":
+			include = r.URL.Query().Get("include")
+			limit = r.URL.Query().Get("limit")
+			w.Write([]byte(`{"objects": [` + strings.Join(objects, ",") + `]}`))
+		case r.URL.Path == "/v1/schema/Review/tenants":
+			w.Write([]byte(`[{"name": "customerC", "activityStatus": "HOT"}, {"name": "customerA", "activityStatus": "COLD"}, {"name": "customerB", "activityStatus": "HOT"}]`))
+		case r.URL.Path == "/v1/schema/Archive/tenants":
+			w.Write([]byte(`[{"name": "customerA", "activityStatus": "COLD"}]`))
+		case r.URL.Path == "/v1/objects" && r.URL.Query().Get("class") == "Review" && r.URL.Query().Get("tenant") == "customerB":
+			w.Write([]byte(`{"objects": [{"class": "Review", "properties": {"text": "good"}}]}`))
+		default:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+	})
+
+	text := []string{"text"}
+	classes := []*models.Class{
+		{Class: "Article", Vectorizer: "text2vec-openai",
+			ModuleConfig: map[string]interface{}{"text2vec-openai": map[string]interface{}{"dimensions": 3.0}},
+			Properties: []*models.Property{
+				{Name: "title", DataType: text},
+				{Name: "body", DataType: text},
+				{Name: "tags", DataType: []string{"text[]"}},
+				{Name: "empty", DataType: text},
+			}},
+		{Class: "Tenants"},
+		{Class: "Review", MultiTenancyConfig: &models.MultiTenancyConfig{Enabled: true},
+			Properties: []*models.Property{{Name: "text", DataType: text}}},
+		{Class: "Archive", MultiTenancyConfig: &models.MultiTenancyConfig{Enabled: true}},
+	}
+
+	// the sample size is bounded
+	samples := getObjectSamples(client, classes, 1000, true)
+	assert.Equal(t, "vector", include)
+	assert.Equal(t, "100", limit)
+	require.Len(t, samples, 4)
+	assert.NotEmpty(t, samples[1].Error)
+
+	// multi-tenant classes are sampled through the first active tenant
+	assert.Equal(t, ClassSample{
+		Class: "Review", Tenant: "customerB", Objects: 1,
+		Properties: []PropertySample{{Name: "text", Filled: 1, TextValues: 1, TextLength: 4}},
+		Dimensions: map[string]map[int]int{},
+	}, samples[2])
+	assert.Equal(t, ClassSample{Class: "Archive", Error: "class Archive has no active tenant"}, samples[3])
+
+	sample := samples[0]
+	assert.Equal(t, 10, sample.Objects)
+	assert.Equal(t, map[string]map[int]int{"": {3: 9, 2: 1}, "title": {2: 10}}, sample.Dimensions)
+	assert.Equal(t, "default: 2 (1), 3 (9); title: 2", sample.DimensionList())
+	assert.Equal(t, PropertySample{Name: "tags", Filled: 10, TextValues: 20, TextLength: 30}, sample.Properties[2])
+	assert.Equal(t, 1.5, sample.Properties[2].AverageTextLength())
+	assert.Equal(t, 0.0, sample.FillRate(sample.Properties[3]))
+	assert.Equal(t, map[string]int{"Article": 3, "Article/title": 2}, sampledDimensions(samples))
+
+	var messages []string
+	for _, validation := range validateObjectSamples(classes, samples) {
+		messages = append(messages, validation.Message)
+	}
+	assert.Equal(t, []string{
+		"The sampled objects of class Article have default vectors of different dimensions: default: 2 (1), 3 (9); title: 2",
+		"Class Article configures 3 dimensions for the default vector, but sampled objects have 2",
+		"Property body of class Article holds 2000 characters on average and is filterable",
+		"Property empty of class Article is not set on any of 10 sampled objects",
+	}, messages)
+	// vectors are only read if asked for
+	getObjectSamples(client, classes[:1], 5, false)
+	assert.Equal(t, "", include)
+}
@@ -0,0 +1,199 @@
+package diagnostics
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/weaviate/weaviate-go-client/v4/weaviate/schema"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+// maxProperties is the number of properties from which on a class is
+// flagged, every property adds buckets to every shard
+const maxProperties = 100
+
+var (
+	// blobLikeProperty matches names of text properties which usually hold
+	// long values nobody searches for with BM25
+	blobLikeProperty = regexp.MustCompile(`(?i)(url|uri|path|hash|checksum|json|base64|html|image|blob)$`)
+	// idLikeProperty matches names of text properties which usually hold
+	// unique identifiers, e.g. id, user_id, userId or externalUUID
+	idLikeProperty = regexp.MustCompile(`(?i:^id$|_id$|uuid$|guid$)|[a-z]Id$`)
+	// timestampProperty matches names of properties which usually track the
+	// creation or update time of an object
+	timestampProperty = regexp.MustCompile(`(?i)^(created|updated|modified|inserted)(_?at|_?on|_?time|_?date)?$|^timestamp$`)
+)
+
+// deprecatedVectorizers maps vectorizer modules to what replaces them.
+var deprecatedVectorizers = map[string]string{
+	"text2vec-contextionary": "is no longer developed, use a transformer based vectorizer such as <code>text2vec-transformers</code>",
+	"text2vec-gpt4all":       "is deprecated, use <code>text2vec-ollama</code> or <code>text2vec-transformers</code>",
+	"text2vec-palm":          "is renamed to <code>text2vec-google</code> in newer Weaviate versions",
+	"multi2vec-palm":         "is renamed to <code>multi2vec-google</code> in newer Weaviate versions",
+}
+
+func isText(property *models.Property) bool {
+	return len(property.DataType) == 1 && (property.DataType[0] == "text" || property.DataType[0] == "string")
+}
+
+// enabled returns the value of an optional index setting, which Weaviate
+// defaults to true.
+func enabled(setting *bool) bool {
+	return setting == nil || *setting
+}
+
+func validateTooManyProperties(class *models.Class) []Validation {
+	if len(class.Properties) <= maxProperties {
+		return nil
+	}
+	return []Validation{{
+		Message: fmt.Sprintf("Class %s has %d properties", class.Class, len(class.Properties)),
+		Hint:    "Every property adds LSM buckets to every shard, which costs memory and file handles. Disable the inverted index on properties which are not filtered or searched, or move rarely used fields to another class",
+	}}
+}
+
+func validateSearchableBlobs(class *models.Class) []Validation {
+	var validations []Validation
+	for _, property := range class.Properties {
+		if !isText(property) || !enabled(property.IndexSearchable) || !blobLikeProperty.MatchString(property.Name) {
+			continue
+		}
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("Property %s of class %s looks like it stores long values which are not searched, but has <code>indexSearchable</code> enabled", property.Name, class.Class),
+			Hint:    "Set <code>indexSearchable: false</code> to skip building a BM25 index for it, this has to be done when creating the property",
+		})
+	}
+	return validations
+}
+
+func validateFilterableIDs(class *models.Class) []Validation {
+	var validations []Validation
+	for _, property := range class.Properties {
+		if !isText(property) || !enabled(property.IndexFilterable) || !idLikeProperty.MatchString(property.Name) {
+			continue
+		}
+		if property.Tokenization == "field" {
+			continue
+		}
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("Property %s of class %s looks like a unique identifier and is filterable with <code>%s</code> tokenization", property.Name, class.Class, tokenizationOrDefault(property.Tokenization)),
+			Hint:    "Word tokenization splits identifiers into many tokens and a filterable index on unique values grows with every object. Use the <code>uuid</code> data type or <code>field</code> tokenization, or set <code>indexFilterable: false</code> if you never filter by it",
+		})
+	}
+	return validations
+}
+
+func tokenizationOrDefault(tokenization string) string {
+	if tokenization == "" {
+		return "word"
+	}
+	return tokenization
+}
+
+func validateTimestamps(class *models.Class) []Validation {
+	if class.InvertedIndexConfig != nil && class.InvertedIndexConfig.IndexTimestamps {
+		return nil
+	}
+	for _, property := range class.Properties {
+		if timestampProperty.MatchString(property.Name) {
+			return []Validation{{
+				Message: fmt.Sprintf("Class %s stores timestamps in property %s but does not index the object timestamps", class.Class, property.Name),
+				Hint:    "Sorting and filtering by <code>_creationTimeUnix</code> or <code>_lastUpdateTimeUnix</code> needs <code>invertedIndexConfig.indexTimestamps: true</code>, which is set when creating the class",
+			}}
+		}
+	}
+	return nil
+}
+
+// classVectorizers returns the vectorizer of the class and of every named
+// vector, leaving out "none" for vectors brought by the user.
+func classVectorizers(class *models.Class) []string {
+	var vectorizers []string
+	if class.Vectorizer != "" && class.Vectorizer != "none" {
+		vectorizers = append(vectorizers, class.Vectorizer)
+	}
+	for _, vectorConfig := range class.VectorConfig {
+		if config, ok := vectorConfig.Vectorizer.(map[string]interface{}); ok {
+			for vectorizer := range config {
+				if vectorizer != "none" {
+					vectorizers = append(vectorizers, vectorizer)
+				}
+			}
+		}
+	}
+	sort.Strings(vectorizers)
+	return vectorizers
+}
+
+func validateDeprecatedVectorizers(class *models.Class) []Validation {
+	var validations []Validation
+	for _, vectorizer := range classVectorizers(class) {
+		if replacement, ok := deprecatedVectorizers[vectorizer]; ok {
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("Class %s uses the vectorizer <code>%s</code>", class.Class, vectorizer),
+				Hint:    fmt.Sprintf("<code>%s</code> %s. Changing the vectorizer requires reimporting the data", vectorizer, replacement),
+			})
+		}
+	}
+
+	// the first generation OpenAI embedding models are retired
+	if moduleConfig, ok := class.ModuleConfig.(map[string]interface{}); ok {
+		if openai, ok := moduleConfig["text2vec-openai"].(map[string]interface{}); ok {
+			if version, _ := openai["modelVersion"].(string); version == "001" {
+				validations = append(validations, Validation{
+					Message: fmt.Sprintf("Class %s uses the retired OpenAI embedding model version 001", class.Class),
+					Hint:    "Move to <code>text-embedding-3-small</code> or <code>text-embedding-3-large</code>, which requires reimporting the data",
+				})
+			}
+		}
+	}
+	return validations
+}
+
+func validateReplicationFactor(class *models.Class, nodeCount int) []Validation {
+	factor := int64(1)
+	if class.ReplicationConfig != nil && class.ReplicationConfig.Factor > 0 {
+		factor = class.ReplicationConfig.Factor
+	}
+
+	switch {
+	case factor > int64(nodeCount):
+		return []Validation{{
+			Message: fmt.Sprintf("Class %s has a replication factor of %d, but the cluster has only %d nodes", class.Class, factor, nodeCount),
+			Hint:    "Replicas of a shard need to be on different nodes, add nodes or lower the replication factor",
+		}}
+	case factor == 1 && nodeCount >= 3:
+		return []Validation{{
+			Message: fmt.Sprintf("Class %s is not replicated although the cluster has %d nodes", class.Class, nodeCount),
+			Hint:    "A single node failure makes the class partly unavailable. Set <code>replicationConfig.factor: 3</code> for high availability",
+		}}
+	case factor%2 == 0:
+		return []Validation{{
+			Message: fmt.Sprintf("Class %s has an even replication factor of %d", class.Class, factor),
+			Hint:    "A quorum of an even number of replicas tolerates as many failures as one replica less. Use an odd factor such as 3",
+		}}
+	}
+	return nil
+}
+
+// validateSchema runs the schema best-practice rules over every class.
+func validateSchema(dump *schema.Dump, nodeCount int) []Validation {
+	var validations []Validation
+	if dump == nil {
+		return validations
+	}
+
+	for _, class := range dump.Classes {
+		validations = append(validations, validateTooManyProperties(class)...)
+		validations = append(validations, validateSearchableBlobs(class)...)
+		validations = append(validations, validateFilterableIDs(class)...)
+		validations = append(validations, validateTimestamps(class)...)
+		validations = append(validations, validateDeprecatedVectorizers(class)...)
+		if nodeCount > 0 {
+			validations = append(validations, validateReplicationFactor(class, nodeCount)...)
+		}
+	}
+
+	return validations
+}
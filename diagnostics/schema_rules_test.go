@@ -0,0 +1,69 @@
+package diagnostics
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate/schema"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+func TestValidateSchema(t *testing.T) {
+	disabled := false
+	text := []string{"text"}
+
+	many := &models.Class{Class: "Wide", ReplicationConfig: &models.ReplicationConfig{Factor: 3}}
+	for i := 0; i <= maxProperties; i++ {
+		many.Properties = append(many.Properties, &models.Property{Name: fmt.Sprintf("p%d", i), DataType: []string{"int"}})
+	}
+
+	dump := &schema.Dump{}
+	dump.Classes = []*models.Class{
+		{
+			Class:             "Article",
+			Vectorizer:        "text2vec-contextionary",
+			ReplicationConfig: &models.ReplicationConfig{Factor: 3},
+			Properties: []*models.Property{
+				{Name: "body", DataType: text},
+				{Name: "imageUrl", DataType: text},
+				{Name: "thumbnailBase64", DataType: text, IndexSearchable: &disabled},
+				{Name: "authorId", DataType: text},
+				{Name: "external_id", DataType: text, Tokenization: "field"},
+				{Name: "valid", DataType: []string{"boolean"}},
+				{Name: "paid", DataType: text},
+				{Name: "createdAt", DataType: []string{"date"}},
+			},
+		},
+		{
+			Class:               "Product",
+			ReplicationConfig:   &models.ReplicationConfig{Factor: 2},
+			InvertedIndexConfig: &models.InvertedIndexConfig{IndexTimestamps: true},
+			ModuleConfig: map[string]interface{}{
+				"text2vec-openai": map[string]interface{}{"model": "ada", "modelVersion": "001"},
+			},
+			Properties: []*models.Property{{Name: "created", DataType: []string{"date"}}},
+		},
+		{Class: "Note"},
+		many,
+	}
+
+	var messages []string
+	for _, validation := range validateSchema(dump, 3) {
+		assert.NotEmpty(t, validation.Hint, validation.Message)
+		messages = append(messages, validation.Message)
+	}
+	assert.Equal(t, []string{
+		"Property imageUrl of class Article looks like it stores long values which are not searched, but has <code>indexSearchable</code> enabled",
+		"Property authorId of class Article looks like a unique identifier and is filterable with <code>word</code> tokenization",
+		"Class Article stores timestamps in property createdAt but does not index the object timestamps",
+		"Class Article uses the vectorizer <code>text2vec-contextionary</code>",
+		"Class Product uses the retired OpenAI embedding model version 001",
+		"Class Product has an even replication factor of 2",
+		"Class Note is not replicated although the cluster has 3 nodes",
+		"Class Wide has 101 properties",
+	}, messages)
+
+	replication := validateReplicationFactor(&models.Class{Class: "Article", ReplicationConfig: &models.ReplicationConfig{Factor: 3}}, 1)
+	assert.Equal(t, "Class Article has a replication factor of 3, but the cluster has only 1 nodes", replication[0].Message)
+}
@@ -0,0 +1,121 @@
+package diagnostics
+
+import (
+	"bytes"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+// ServerHost describes a Weaviate node as reported by the cluster itself over
+// /v1/nodes, as opposed to the collector host running this tool.
+type ServerHost struct {
+	Name    string
+	Version string
+	GitHash string
+	Status  string
+	Shards  int64
+	Objects int64
+
+	// Agent is set if an agent runs next to the node
+	Agent *AgentInfo
+}
+
+// ServerRuntime holds the Go runtime and process metrics of the node serving
+// the Prometheus endpoint.
+type ServerRuntime struct {
+	Source          string
+	GoVersion       string
+	Goroutines      float64
+	Threads         float64
+	HeapInuseBytes  float64
+	SysBytes        float64
+	ResidentBytes   float64
+	CPUSeconds      float64
+	OpenFDs         float64
+	MaxFDs          float64
+	MetricsReceived bool
+}
+
+func getServerHosts(nodes []*models.NodeStatus) []ServerHost {
+	var hosts []ServerHost
+	for _, node := range nodes {
+		host := ServerHost{
+			Name:    node.Name,
+			Version: node.Version,
+			GitHash: node.GitHash,
+		}
+		if node.Status != nil {
+			host.Status = *node.Status
+		}
+		if node.Stats != nil {
+			host.Shards = node.Stats.ShardCount
+			host.Objects = node.Stats.ObjectCount
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+func parsePrometheusMetrics(data []byte) (map[string]*dto.MetricFamily, error) {
+	var parser expfmt.TextParser
+	return parser.TextToMetricFamilies(bytes.NewReader(data))
+}
+
+// metricValue returns the sum of all series of a gauge, counter or untyped
+// metric, the number of observations of a histogram or summary, or 0 if the
+// metric is not present.
+func metricValue(families map[string]*dto.MetricFamily, name string) float64 {
+	family, ok := families[name]
+	if !ok {
+		return 0
+	}
+
+	var sum float64
+	for _, metric := range family.GetMetric() {
+		switch {
+		case metric.Gauge != nil:
+			sum += metric.GetGauge().GetValue()
+		case metric.Counter != nil:
+			sum += metric.GetCounter().GetValue()
+		case metric.Untyped != nil:
+			sum += metric.GetUntyped().GetValue()
+		case metric.Histogram != nil:
+			sum += float64(metric.GetHistogram().GetSampleCount())
+		case metric.Summary != nil:
+			sum += float64(metric.GetSummary().GetSampleCount())
+		}
+	}
+	return sum
+}
+
+// metricLabel returns the value of a label of the first series of a metric.
+func metricLabel(families map[string]*dto.MetricFamily, name string, label string) string {
+	family, ok := families[name]
+	if !ok || len(family.GetMetric()) == 0 {
+		return ""
+	}
+	for _, pair := range family.GetMetric()[0].GetLabel() {
+		if pair.GetName() == label {
+			return pair.GetValue()
+		}
+	}
+	return ""
+}
+
+func getServerRuntime(source string, families map[string]*dto.MetricFamily) ServerRuntime {
+	return ServerRuntime{
+		Source:          source,
+		GoVersion:       metricLabel(families, "go_info", "version"),
+		Goroutines:      metricValue(families, "go_goroutines"),
+		Threads:         metricValue(families, "go_threads"),
+		HeapInuseBytes:  metricValue(families, "go_memstats_heap_inuse_bytes"),
+		SysBytes:        metricValue(families, "go_memstats_sys_bytes"),
+		ResidentBytes:   metricValue(families, "process_resident_memory_bytes"),
+		CPUSeconds:      metricValue(families, "process_cpu_seconds_total"),
+		OpenFDs:         metricValue(families, "process_open_fds"),
+		MaxFDs:          metricValue(families, "process_max_fds"),
+		MetricsReceived: len(families) > 0,
+	}
+}
@@ -0,0 +1,58 @@
+package diagnostics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+const sampleMetrics = `# HELP go_goroutines Number of goroutines that currently exist.
+# TYPE go_goroutines gauge
+go_goroutines 312
+# HELP go_info Information about the Go environment.
+# TYPE go_info gauge
+go_info{version="go1.21.8"} 1
+# TYPE go_memstats_heap_inuse_bytes gauge
+go_memstats_heap_inuse_bytes 1.048576e+06
+# TYPE process_resident_memory_bytes gauge
+process_resident_memory_bytes 2.097152e+06
+# TYPE process_cpu_seconds_total counter
+process_cpu_seconds_total 42.5
+# TYPE process_open_fds gauge
+process_open_fds 120
+# TYPE process_max_fds gauge
+process_max_fds 65536
+`
+
+func TestGetServerRuntime(t *testing.T) {
+	families, err := parsePrometheusMetrics([]byte(sampleMetrics))
+	require.NoError(t, err)
+
+	runtime := getServerRuntime("http://weaviate:2112/metrics", families)
+	assert.True(t, runtime.MetricsReceived)
+	assert.Equal(t, "go1.21.8", runtime.GoVersion)
+	assert.Equal(t, float64(312), runtime.Goroutines)
+	assert.Equal(t, float64(1<<20), runtime.HeapInuseBytes)
+	assert.Equal(t, float64(2<<20), runtime.ResidentBytes)
+	assert.Equal(t, 42.5, runtime.CPUSeconds)
+	assert.Equal(t, float64(65536), runtime.MaxFDs)
+	assert.Zero(t, runtime.Threads)
+	assert.False(t, getServerRuntime("", nil).MetricsReceived)
+}
+
+func TestGetServerHosts(t *testing.T) {
+	status := models.NodeStatusStatusHEALTHY
+	nodes := []*models.NodeStatus{
+		{Name: "weaviate-0", Version: "1.24.10", GitHash: "abc123", Status: &status,
+			Stats: &models.NodeStats{ShardCount: 3, ObjectCount: 1000}},
+		{Name: "weaviate-1", Version: "1.24.10"},
+	}
+
+	hosts := getServerHosts(nodes)
+	require.Len(t, hosts, 2)
+	assert.Equal(t, ServerHost{Name: "weaviate-0", Version: "1.24.10", GitHash: "abc123",
+		Status: "HEALTHY", Shards: 3, Objects: 1000}, hosts[0])
+	assert.Empty(t, hosts[1].Status)
+}
@@ -0,0 +1,153 @@
+package diagnostics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+// ShardSummary aggregates the shards of one class on one node from the
+// verbose /v1/nodes output.
+type ShardSummary struct {
+	Class       string
+	Node        string
+	Shards      int
+	Objects     int64
+	Statuses    map[string]int
+	QueueLength int64
+	Compressed  int
+	Unloaded    int
+}
+
+// StatusList formats the vector indexing statuses as e.g. "INDEXING 1, READY 3".
+func (s ShardSummary) StatusList() string {
+	var statuses []string
+	for status, count := range s.Statuses {
+		statuses = append(statuses, fmt.Sprintf("%s %d", status, count))
+	}
+	sort.Strings(statuses)
+	return strings.Join(statuses, ", ")
+}
+
+const (
+	shardStatusReadOnly = "READONLY"
+	nodeStatusHealthy   = "HEALTHY"
+)
+
+func getShardSummaries(nodes []*models.NodeStatus) []ShardSummary {
+	summaries := map[[2]string]*ShardSummary{}
+	for _, node := range nodes {
+		for _, shard := range node.Shards {
+			key := [2]string{shard.Class, node.Name}
+			summary, ok := summaries[key]
+			if !ok {
+				summary = &ShardSummary{Class: shard.Class, Node: node.Name, Statuses: map[string]int{}}
+				summaries[key] = summary
+			}
+			summary.Shards++
+			summary.Objects += shard.ObjectCount
+			summary.QueueLength += shard.VectorQueueLength
+			summary.Statuses[shard.VectorIndexingStatus]++
+			if shard.Compressed {
+				summary.Compressed++
+			}
+			if !shard.Loaded {
+				summary.Unloaded++
+			}
+		}
+	}
+
+	var result []ShardSummary
+	for _, summary := range summaries {
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(a, b int) bool {
+		if result[a].Class == result[b].Class {
+			return result[a].Node < result[b].Node
+		}
+		return result[a].Class < result[b].Class
+	})
+	return result
+}
+
+// maxListedShards is the number of shard names listed per finding
+const maxListedShards = 5
+
+func validateShards(nodes []*models.NodeStatus) []Validation {
+	var validations []Validation
+
+	for _, node := range nodes {
+		if node.Status != nil && *node.Status != nodeStatusHealthy {
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("Node %s is %s", node.Name, *node.Status),
+			})
+		}
+
+		var readOnly []string
+		for _, shard := range node.Shards {
+			if shard.VectorIndexingStatus == shardStatusReadOnly {
+				readOnly = append(readOnly, fmt.Sprintf("%s/%s", shard.Class, shard.Name))
+			}
+		}
+		if len(readOnly) > 0 {
+			listed := readOnly
+			if len(listed) > maxListedShards {
+				listed = append(listed[:maxListedShards:maxListedShards], "...")
+			}
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("%d shards on node %s are READONLY, usually because the disk usage crossed <code>DISK_USE_READONLY_PERCENTAGE</code>: %s",
+					len(readOnly), node.Name, strings.Join(listed, ", ")),
+			})
+		}
+	}
+
+	counts := map[string]int{}
+	for _, node := range nodes {
+		counts[node.Name] = len(node.Shards)
+	}
+	for _, overloaded := range findOverloadedNodes(counts) {
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("Shards are unbalanced: node %s holds %d shards, the average is %.1f and the least loaded node holds %d",
+				overloaded.Node, overloaded.Count, overloaded.Mean, overloaded.Min),
+		})
+	}
+
+	return validations
+}
+
+type overloadedNode struct {
+	Node  string
+	Count int
+	Mean  float64
+	Min   int
+}
+
+// findOverloadedNodes returns the nodes which hold 50% more than the average
+// and at least two more than the emptiest node, sorted by name.
+func findOverloadedNodes(counts map[string]int) []overloadedNode {
+	if len(counts) < 2 {
+		return nil
+	}
+
+	total, min := 0, -1
+	for _, count := range counts {
+		total += count
+		if min < 0 || count < min {
+			min = count
+		}
+	}
+	mean := float64(total) / float64(len(counts))
+
+	var overloaded []overloadedNode
+	for node, count := range counts {
+		if float64(count) > 1.5*mean && count-min > 1 {
+			overloaded = append(overloaded, overloadedNode{Node: node, Count: count, Mean: mean, Min: min})
+		}
+	}
+	sort.Slice(overloaded, func(a, b int) bool {
+		return overloaded[a].Node < overloaded[b].Node
+	})
+	return overloaded
+}
@@ -0,0 +1,51 @@
+package diagnostics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+func testNodes() []*models.NodeStatus {
+	healthy, unhealthy := "HEALTHY", "UNHEALTHY"
+	shard := func(class string, name string, status string, objects int64) *models.NodeShardStatus {
+		return &models.NodeShardStatus{Class: class, Name: name, VectorIndexingStatus: status,
+			ObjectCount: objects, Loaded: true, Compressed: class == "Article", VectorQueueLength: 10}
+	}
+	return []*models.NodeStatus{
+		{Name: "weaviate-0", Status: &healthy, Shards: []*models.NodeShardStatus{
+			shard("Article", "a1", "READY", 100),
+			shard("Article", "a2", "INDEXING", 50),
+			shard("Article", "a3", "READONLY", 10),
+			shard("Product", "p1", "READY", 5),
+			shard("Product", "p2", "READY", 5),
+		}},
+		{Name: "weaviate-1", Status: &unhealthy, Shards: []*models.NodeShardStatus{
+			shard("Product", "p3", "READY", 7),
+		}},
+		{Name: "weaviate-2", Status: &healthy},
+	}
+}
+
+func TestGetShardSummaries(t *testing.T) {
+	summaries := getShardSummaries(testNodes())
+	require.Len(t, summaries, 3)
+
+	assert.Equal(t, ShardSummary{Class: "Article", Node: "weaviate-0", Shards: 3, Objects: 160,
+		Statuses: map[string]int{"READY": 1, "INDEXING": 1, "READONLY": 1}, QueueLength: 30, Compressed: 3}, summaries[0])
+	assert.Equal(t, "INDEXING 1, READONLY 1, READY 1", summaries[0].StatusList())
+	assert.Equal(t, "weaviate-1", summaries[2].Node)
+}
+
+func TestValidateShards(t *testing.T) {
+	assert.Equal(t, []Validation{
+		{Message: "1 shards on node weaviate-0 are READONLY, usually because the disk usage crossed <code>DISK_USE_READONLY_PERCENTAGE</code>: Article/a3"},
+		{Message: "Node weaviate-1 is UNHEALTHY"},
+		{Message: "Shards are unbalanced: node weaviate-0 holds 5 shards, the average is 2.0 and the least loaded node holds 0"},
+	}, validateShards(testNodes()))
+
+	// a single node cannot be unbalanced
+	assert.Len(t, validateShards(testNodes()[:1]), 1)
+}
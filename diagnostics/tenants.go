@@ -0,0 +1,208 @@
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/weaviate/weaviate-go-client/v4/weaviate"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+// TenantSummary describes the tenants of a multi-tenant class.
+type TenantSummary struct {
+	Class   string
+	Tenants int
+	// Statuses counts the tenants by activity status, e.g. HOT or COLD
+	Statuses map[string]int
+	// ActivePerNode counts the loaded tenants per node from /v1/nodes
+	ActivePerNode map[string]int
+
+	AutoTenantCreation   bool
+	AutoTenantActivation bool
+
+	// CaseDuplicates are tenant names which only differ in case, a common
+	// result of typos with auto tenant creation
+	CaseDuplicates []string
+}
+
+// StatusList formats the activity statuses as e.g. "COLD 10, HOT 2".
+func (s TenantSummary) StatusList() string {
+	var statuses []string
+	for status, count := range s.Statuses {
+		statuses = append(statuses, fmt.Sprintf("%s %d", status, count))
+	}
+	sort.Strings(statuses)
+	return strings.Join(statuses, ", ")
+}
+
+// MaxActivePerNode is the highest number of active tenants of the class on a
+// single node.
+func (s TenantSummary) MaxActivePerNode() int {
+	max := 0
+	for _, count := range s.ActivePerNode {
+		if count > max {
+			max = count
+		}
+	}
+	return max
+}
+
+// multiTenancyConfig holds the multi-tenancy settings of a class including
+// the ones the schema model of the vendored Weaviate version does not know.
+type multiTenancyConfig struct {
+	Enabled              bool `json:"enabled"`
+	AutoTenantCreation   bool `json:"autoTenantCreation"`
+	AutoTenantActivation bool `json:"autoTenantActivation"`
+}
+
+// activeTenantStatuses are the activity statuses of loaded tenants, Weaviate
+// 1.26 renamed HOT to ACTIVE and older versions may leave it empty
+var activeTenantStatuses = map[string]bool{"": true, models.TenantActivityStatusHOT: true, "ACTIVE": true}
+
+// activeTenant returns the first active tenant by name of a multi-tenant
+// class, as objects of such classes can only be read through a tenant, and ""
+// for other classes.
+func activeTenant(client *weaviate.Client, class *models.Class) (string, error) {
+	if class.MultiTenancyConfig == nil || !class.MultiTenancyConfig.Enabled {
+		return "", nil
+	}
+	tenants, err := client.Schema().TenantsGetter().WithClassName(class.Class).Do(context.Background())
+	if err != nil {
+		return "", err
+	}
+	var active []string
+	for _, tenant := range tenants {
+		if activeTenantStatuses[tenant.ActivityStatus] {
+			active = append(active, tenant.Name)
+		}
+	}
+	if len(active) == 0 {
+		return "", fmt.Errorf("class %s has no active tenant", class.Class)
+	}
+	sort.Strings(active)
+	return active[0], nil
+}
+
+// autoTenantVersion is the first Weaviate version supporting auto tenant
+// creation and activation
+const autoTenantVersion = "1.25.0"
+
+// findCaseDuplicates returns the tenant names which only differ in case.
+func findCaseDuplicates(tenants []models.Tenant) []string {
+	byLower := map[string][]string{}
+	for _, tenant := range tenants {
+		lower := strings.ToLower(tenant.Name)
+		byLower[lower] = append(byLower[lower], tenant.Name)
+	}
+
+	var duplicates []string
+	for _, names := range byLower {
+		if len(names) > 1 {
+			duplicates = append(duplicates, names...)
+		}
+	}
+	sort.Strings(duplicates)
+	return duplicates
+}
+
+func getTenantSummary(class string, tenants []models.Tenant, config multiTenancyConfig, nodes []*models.NodeStatus) TenantSummary {
+	summary := TenantSummary{
+		Class:                class,
+		Tenants:              len(tenants),
+		Statuses:             map[string]int{},
+		ActivePerNode:        map[string]int{},
+		AutoTenantCreation:   config.AutoTenantCreation,
+		AutoTenantActivation: config.AutoTenantActivation,
+		CaseDuplicates:       findCaseDuplicates(tenants),
+	}
+
+	for _, tenant := range tenants {
+		status := tenant.ActivityStatus
+		if status == "" {
+			status = models.TenantActivityStatusHOT
+		}
+		summary.Statuses[status]++
+	}
+
+	// every tenant is a shard named after it, only active ones are listed
+	for _, node := range nodes {
+		summary.ActivePerNode[node.Name] = 0
+		for _, shard := range node.Shards {
+			if shard.Class == class {
+				summary.ActivePerNode[node.Name]++
+			}
+		}
+	}
+
+	return summary
+}
+
+func validateTenants(tenants []TenantSummary, version string, maxActivePerNode int) []Validation {
+	var validations []Validation
+
+	for _, summary := range tenants {
+		nodes := make([]string, 0, len(summary.ActivePerNode))
+		for node := range summary.ActivePerNode {
+			nodes = append(nodes, node)
+		}
+		sort.Strings(nodes)
+		for _, node := range nodes {
+			if count := summary.ActivePerNode[node]; count > maxActivePerNode {
+				validations = append(validations, Validation{
+					Message: fmt.Sprintf("Class %s has %d active tenants on node %s, more than %d. Deactivate unused tenants by setting them COLD",
+						summary.Class, count, node, maxActivePerNode),
+				})
+			}
+		}
+
+		for _, overloaded := range findOverloadedNodes(summary.ActivePerNode) {
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("Tenants of class %s are unbalanced: node %s holds %d active tenants, the average is %.1f",
+					summary.Class, overloaded.Node, overloaded.Count, overloaded.Mean),
+			})
+		}
+
+		if (summary.AutoTenantCreation || summary.AutoTenantActivation) && version != "" && compareVersions(version, autoTenantVersion) < 0 {
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("Class %s enables auto tenant creation or activation, which Weaviate %s does not support before %s. The setting is ignored",
+					summary.Class, version, autoTenantVersion),
+			})
+		}
+
+		if summary.AutoTenantCreation && len(summary.CaseDuplicates) > 0 {
+			listed := summary.CaseDuplicates
+			if len(listed) > maxListedShards {
+				listed = append(listed[:maxListedShards:maxListedShards], "...")
+			}
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("Class %s creates tenants automatically and has tenants whose names only differ in case, likely created by typos: %s",
+					summary.Class, strings.Join(listed, ", ")),
+			})
+		}
+	}
+
+	return validations
+}
+
+// validateMultiTenancyConfigs flags auto tenant settings on classes without
+// multi-tenancy, where they have no effect.
+func validateMultiTenancyConfigs(settings map[string]classSettings) []Validation {
+	var classes []string
+	for class, classSettings := range settings {
+		config := classSettings.MultiTenancyConfig
+		if !config.Enabled && (config.AutoTenantCreation || config.AutoTenantActivation) {
+			classes = append(classes, class)
+		}
+	}
+	sort.Strings(classes)
+
+	var validations []Validation
+	for _, class := range classes {
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("Class %s enables auto tenant creation or activation without multi-tenancy, the setting has no effect", class),
+		})
+	}
+	return validations
+}
@@ -0,0 +1,82 @@
+package diagnostics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+func TestGetClassSettings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/schema", r.URL.Path)
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"classes": [
+			{"class": "Article", "multiTenancyConfig": {"enabled": true, "autoTenantCreation": true},
+			 "replicationConfig": {"factor": 3, "asyncEnabled": true}},
+			{"class": "Product", "multiTenancyConfig": {"enabled": false, "autoTenantActivation": true}},
+			{"class": "Legacy"}
+		]}`))
+	}))
+	defer server.Close()
+
+	settings, err := getClassSettings(server.URL+"/", "key")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]classSettings{
+		"Article": {
+			MultiTenancyConfig: multiTenancyConfig{Enabled: true, AutoTenantCreation: true},
+			ReplicationConfig:  replicationConfig{Factor: 3, AsyncEnabled: true},
+		},
+		"Product": {MultiTenancyConfig: multiTenancyConfig{AutoTenantActivation: true}},
+		"Legacy":  {},
+	}, settings)
+
+	assert.Equal(t, []Validation{
+		{Message: "Class Product enables auto tenant creation or activation without multi-tenancy, the setting has no effect"},
+	}, validateMultiTenancyConfigs(settings))
+}
+
+func TestTenantSummary(t *testing.T) {
+	tenants := []models.Tenant{
+		{Name: "customerA", ActivityStatus: "HOT"},
+		{Name: "CustomerA", ActivityStatus: "HOT"},
+		{Name: "customerB"},
+		{Name: "customerC", ActivityStatus: "COLD"},
+	}
+	activeShard := func(name string) *models.NodeShardStatus {
+		return &models.NodeShardStatus{Class: "Article", Name: name}
+	}
+	nodes := []*models.NodeStatus{
+		{Name: "weaviate-0", Shards: []*models.NodeShardStatus{activeShard("customerA"), activeShard("CustomerA"), activeShard("customerB")}},
+		{Name: "weaviate-1", Shards: []*models.NodeShardStatus{{Class: "Product", Name: "p1"}}},
+	}
+
+	summary := getTenantSummary("Article", tenants, multiTenancyConfig{Enabled: true, AutoTenantCreation: true}, nodes)
+	assert.Equal(t, 4, summary.Tenants)
+	assert.Equal(t, "COLD 1, HOT 3", summary.StatusList())
+	assert.Equal(t, map[string]int{"weaviate-0": 3, "weaviate-1": 0}, summary.ActivePerNode)
+	assert.Equal(t, 3, summary.MaxActivePerNode())
+	assert.Equal(t, []string{"CustomerA", "customerA"}, summary.CaseDuplicates)
+
+	assert.Equal(t, []Validation{
+		{Message: "Class Article has 3 active tenants on node weaviate-0, more than 2. Deactivate unused tenants by setting them COLD"},
+		{Message: "Tenants of class Article are unbalanced: node weaviate-0 holds 3 active tenants, the average is 1.5"},
+		{Message: "Class Article enables auto tenant creation or activation, which Weaviate 1.24.10 does not support before 1.25.0. The setting is ignored"},
+		{Message: "Class Article creates tenants automatically and has tenants whose names only differ in case, likely created by typos: CustomerA, customerA"},
+	}, validateTenants([]TenantSummary{summary}, "1.24.10", 2))
+	assert.Len(t, validateTenants([]TenantSummary{summary}, "1.25.1", 5000), 2)
+}
+
+func TestValidateTenantsNodeOrder(t *testing.T) {
+	summary := TenantSummary{Class: "Article", ActivePerNode: map[string]int{"weaviate-2": 5, "weaviate-0": 5, "weaviate-1": 5}}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, []Validation{
+			{Message: "Class Article has 5 active tenants on node weaviate-0, more than 4. Deactivate unused tenants by setting them COLD"},
+			{Message: "Class Article has 5 active tenants on node weaviate-1, more than 4. Deactivate unused tenants by setting them COLD"},
+			{Message: "Class Article has 5 active tenants on node weaviate-2, more than 4. Deactivate unused tenants by setting them COLD"},
+		}, validateTenants([]TenantSummary{summary}, "1.25.1", 4))
+	}
+}
@@ -2,56 +2,119 @@ package diagnostics
 
 import (
 	"fmt"
-	"os"
+	"html"
+	"io"
+	"regexp"
 	"strconv"
 	"strings"
 
+	"github.com/weaviate/weaviate-diagnostics/utilities"
 	"github.com/weaviate/weaviate-go-client/v4/weaviate/schema"
 )
 
 type Validation struct {
 	Message string
+	// Hint explains how to remediate the issue, if known
+	Hint string
 }
 
-func validateEnvironmentVariables() []Validation {
-	var validations []Validation
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
 
-	if os.Getenv("GOMEMLIMIT") == "" {
-		validations = append(validations, Validation{
-			Message: "<code>GOMEMLIMIT</code> is not set",
-		})
+// plainText turns the HTML of a validation message or hint into text for the
+// terminal.
+func plainText(s string) string {
+	return html.UnescapeString(htmlTag.ReplaceAllString(s, ""))
+}
+
+// printValidations writes the validations as plain text, every hint indented
+// below its message.
+func printValidations(w io.Writer, validations []Validation) {
+	for _, validation := range validations {
+		fmt.Fprintln(w, plainText(validation.Message))
+		if validation.Hint != "" {
+			fmt.Fprintf(w, "  %s\n", plainText(validation.Hint))
+		}
 	}
+}
+
+// envRule checks the environment of the Weaviate versions in Versions, a
+// range as understood by versionInRange.
+type envRule struct {
+	Versions string
+	Check    func(getenv func(string) string, version string) []Validation
+}
 
-	if os.Getenv("QUERY_MAXIMUM_RESULTS") != "" {
-		max_results, err := strconv.ParseInt(os.Getenv("QUERY_MAXIMUM_RESULTS"), 10, 64)
+var envRules = []envRule{
+	{Check: func(getenv func(string) string, version string) []Validation {
+		if getenv("GOMEMLIMIT") == "" {
+			return []Validation{{
+				Message: "<code>GOMEMLIMIT</code> is not set",
+			}}
+		}
+		return nil
+	}},
+	{Check: func(getenv func(string) string, version string) []Validation {
+		if getenv("QUERY_MAXIMUM_RESULTS") == "" {
+			return nil
+		}
+		max_results, err := strconv.ParseInt(getenv("QUERY_MAXIMUM_RESULTS"), 10, 64)
 		if err != nil {
-			validations = append(validations, Validation{
+			return []Validation{{
 				Message: fmt.Sprintf("<code>QUERY_MAXIMUM_RESULTS</code> is not a number: %s", err),
-			})
+			}}
 		} else if max_results > 10000 {
-			validations = append(validations, Validation{
+			return []Validation{{
 				Message: fmt.Sprintf("<code>QUERY_MAXIMUM_RESULTS</code> is set high: %d", max_results),
-			})
+			}}
 		}
-	}
-
-	if os.Getenv("GOGC") != "" {
-		max_results, err := strconv.ParseInt(os.Getenv("GOGC"), 10, 64)
+		return nil
+	}},
+	{Check: func(getenv func(string) string, version string) []Validation {
+		if getenv("GOGC") == "" {
+			return nil
+		}
+		max_results, err := strconv.ParseInt(getenv("GOGC"), 10, 64)
 		if err != nil {
-			validations = append(validations, Validation{
+			return []Validation{{
 				Message: fmt.Sprintf("<code>GOGC</code> is not a number: %s", err),
-			})
+			}}
 		} else if max_results != 100 {
-			validations = append(validations, Validation{
+			return []Validation{{
 				Message: fmt.Sprintf("<code>GOGC</code> is set: %d", max_results),
-			})
+			}}
 		}
+		return nil
+	}},
+	// older versions need the reindex once to backfill the dimensions of
+	// existing vectors
+	{Versions: ">=1.19.0", Check: func(getenv func(string) string, version string) []Validation {
+		if strings.ToLower(getenv("REINDEX_VECTOR_DIMENSIONS_AT_STARTUP")) == "true" || getenv("REINDEX_VECTOR_DIMENSIONS_AT_STARTUP") == "1" {
+			return []Validation{{
+				Message: fmt.Sprintf("<code>REINDEX_VECTOR_DIMENSIONS_AT_STARTUP</code> is set to true. Weaviate %s tracks vector dimensions on import", versionOrUnknown(version)),
+				Hint:    "The reindex is only needed once after upgrading from a version before 1.19 and slows down every startup, remove the variable",
+			}}
+		}
+		return nil
+	}},
+}
+
+func versionOrUnknown(version string) string {
+	if version == "" {
+		return "(unknown version)"
 	}
+	return version
+}
 
-	if strings.ToLower(os.Getenv("REINDEX_VECTOR_DIMENSIONS_AT_STARTUP")) == "true" || os.Getenv("REINDEX_VECTOR_DIMENSIONS_AT_STARTUP") == "1" {
-		validations = append(validations, Validation{
-			Message: "<code>REINDEX_VECTOR_DIMENSIONS_AT_STARTUP</code> is set to true. This is likely not needed if running on a recent version of Weaviate.",
-		})
+// validateEnvironmentVariables runs the environment rules for the Weaviate
+// version. Rules with a version range also run if the version is unknown.
+func validateEnvironmentVariables(getenv func(string) string, version string) []Validation {
+	var validations []Validation
+
+	for _, rule := range envRules {
+		if version != "" && !versionInRange(version, rule.Versions) {
+			continue
+		}
+		validations = append(validations, rule.Check(getenv, version)...)
 	}
 
 	return validations
@@ -85,11 +148,197 @@ func validateBadVectorIndexConfig(schema *schema.Dump) []Validation {
 	return validations
 }
 
-func validate(schema *schema.Dump) []Validation {
+// mmapUnfriendlyFilesystems are filesystems on which memory mapped files
+// are slow or unreliable
+var mmapUnfriendlyFilesystems = []string{"nfs", "nfs4", "cifs", "smb3", "9p", "fuse"}
+
+func validateHostInfo(hostInfo HostInfo) []Validation {
+	var validations []Validation
+
+	if hostInfo.MaxMapCount > 0 && hostInfo.MaxMapCount < 262144 {
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("<code>vm.max_map_count</code> is set low: %d. Weaviate memory maps every LSM segment, raise it to at least 262144", hostInfo.MaxMapCount),
+		})
+	}
+
+	for _, fsType := range mmapUnfriendlyFilesystems {
+		if hostInfo.FilesystemType == fsType || strings.HasPrefix(hostInfo.FilesystemType, fsType+".") {
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("The data directory is on a %s filesystem, memory mapped files are slow or unreliable on it", hostInfo.FilesystemType),
+			})
+		}
+	}
+
+	if hostInfo.TransparentHugepages == "always" {
+		validations = append(validations, Validation{
+			Message: "Transparent hugepages are set to <code>always</code>, this can inflate memory usage. <code>madvise</code> is recommended",
+		})
+	}
+
+	if hostInfo.OpenFilesSoft > 0 && hostInfo.OpenFilesSoft < 65536 {
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("The open files limit is set low: %d", hostInfo.OpenFilesSoft),
+		})
+	}
+
+	if hostInfo.SwapTotal > 0 {
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("Swap is enabled (%s), swapping the vector index makes queries very slow", utilities.FormatBytes(hostInfo.SwapTotal)),
+		})
+	}
+
+	return validations
+}
+
+// validateServerHosts runs the host and environment checks against the data
+// the agents collected on the Weaviate nodes.
+func validateServerHosts(hosts []ServerHost) []Validation {
+	var validations []Validation
+
+	for _, host := range hosts {
+		if host.Agent == nil {
+			continue
+		}
+		env := host.Agent.Env
+		nodeValidations := validateEnvironmentVariables(func(name string) string { return env[name] }, host.Version)
+		nodeValidations = append(nodeValidations, validateHostInfo(host.Agent.Host)...)
+		for _, validation := range nodeValidations {
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("Node %s: %s", host.Name, validation.Message),
+				Hint:    validation.Hint,
+			})
+		}
+	}
+
+	return validations
+}
+
+// serverGetenv returns a lookup into the environment of the Weaviate server
+// from the Docker container, the Kubernetes pods or the local Weaviate process.
+// It is nil if the agents report the environment of the nodes themselves or
+// none of them is known, the collector's own environment says nothing about
+// the server.
+func serverGetenv(hosts []ServerHost, docker *DockerContainer, kube *KubeReport, localEnv map[string]string) func(string) string {
+	for _, host := range hosts {
+		if host.Agent != nil {
+			return nil
+		}
+	}
+
+	if docker != nil {
+		return docker.Getenv
+	}
+
+	// the pods of a StatefulSet share the container spec
+	if kube != nil && len(kube.Pods) > 0 {
+		for _, container := range kube.Pods[0].Containers {
+			if container.Name != weaviateContainer {
+				continue
+			}
+			env := map[string]string{}
+			for _, v := range container.Env {
+				env[v.Name] = v.Value
+			}
+			return func(name string) string { return env[name] }
+		}
+	}
+
+	if localEnv != nil {
+		return func(name string) string { return localEnv[name] }
+	}
+
+	return nil
+}
+
+// validate runs the schema, environment and host checks. The environment rules
+// are skipped if getenv is nil.
+func validate(schema *schema.Dump, hostInfo HostInfo, getenv func(string) string, version string, serverHosts []ServerHost) []Validation {
 	var validations []Validation
 
 	validations = append(validations, validateBadVectorIndexConfig(schema)...)
-	validations = append(validations, validateEnvironmentVariables()...)
+	validations = append(validations, validateSchema(schema, len(serverHosts))...)
+	if getenv != nil {
+		validations = append(validations, validateEnvironmentVariables(getenv, version)...)
+	}
+	// the collector host settings only matter if weaviate runs on it
+	if hostInfo.LocalWeaviate {
+		validations = append(validations, validateHostInfo(hostInfo)...)
+	}
+	validations = append(validations, validateServerHosts(serverHosts)...)
+
+	return validations
+}
+
+func validateKube(kube *KubeReport) []Validation {
+	var validations []Validation
+	if kube == nil {
+		return validations
+	}
+
+	if kube.ReadyReplicas < kube.Replicas {
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("Only %d of %d replicas of StatefulSet %s are ready", kube.ReadyReplicas, kube.Replicas, kube.StatefulSet),
+		})
+	}
+
+	for _, pod := range kube.Pods {
+		for _, container := range pod.Containers {
+			if container.Name != weaviateContainer {
+				continue
+			}
+			if container.MemoryLimit == 0 {
+				validations = append(validations, Validation{
+					Message: fmt.Sprintf("Pod %s has no memory limit, set one together with <code>GOMEMLIMIT</code>", pod.Name),
+				})
+			}
+			if container.LastTerminationReason == "OOMKilled" {
+				validations = append(validations, Validation{
+					Message: fmt.Sprintf("Pod %s was OOM killed, %d restarts", pod.Name, container.Restarts),
+				})
+			} else if container.Restarts > 0 {
+				validations = append(validations, Validation{
+					Message: fmt.Sprintf("Pod %s restarted %d times, last termination reason: %s", pod.Name, container.Restarts, container.LastTerminationReason),
+				})
+			}
+		}
+	}
+
+	for _, pvc := range kube.PVCs {
+		if pvc.Phase != "Bound" {
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("Volume claim %s of pod %s is %s", pvc.Name, pvc.Pod, pvc.Phase),
+			})
+		}
+	}
+
+	return validations
+}
+
+func validateDocker(container *DockerContainer) []Validation {
+	var validations []Validation
+	if container == nil {
+		return validations
+	}
+
+	if container.MemoryLimit == 0 {
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("Container %s has no memory limit, set one together with <code>GOMEMLIMIT</code>", container.Name),
+		})
+	}
+	if container.OOMKilled {
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("Container %s was OOM killed, %d restarts", container.Name, container.RestartCount),
+		})
+	} else if container.RestartCount > 0 {
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("Container %s restarted %d times", container.Name, container.RestartCount),
+		})
+	}
+	if container.Health == "unhealthy" {
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("Container %s is unhealthy", container.Name),
+		})
+	}
 
 	return validations
 }
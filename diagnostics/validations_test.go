@@ -1,6 +1,7 @@
 package diagnostics
 
 import (
+	"bytes"
 	"os"
 	"testing"
 
@@ -62,9 +63,62 @@ func TestEnvironmentVariables(t *testing.T) {
 			Message: "<code>GOGC</code> is set: 200",
 		},
 		{
-			Message: "<code>REINDEX_VECTOR_DIMENSIONS_AT_STARTUP</code> is set to true. This is likely not needed if running on a recent version of Weaviate.",
+			Message: "<code>REINDEX_VECTOR_DIMENSIONS_AT_STARTUP</code> is set to true. Weaviate 1.24.8 tracks vector dimensions on import",
+			Hint:    "The reindex is only needed once after upgrading from a version before 1.19 and slows down every startup, remove the variable",
 		},
 	}
-	validations := validateEnvironmentVariables()
+	validations := validateEnvironmentVariables(os.Getenv, "1.24.8")
+	assert.Equal(t, assumed, validations)
+
+	// older versions need the reindex
+	assert.Equal(t, assumed[:3], validateEnvironmentVariables(os.Getenv, "1.18.3"))
+}
+
+func TestServerGetenv(t *testing.T) {
+	hosts := []ServerHost{{Name: "weaviate-0"}}
+	docker := &DockerContainer{Env: map[string]string{"GOMEMLIMIT": "1GiB"}}
+	kube := &KubeReport{Pods: []KubePod{{Containers: []KubeContainer{
+		{Name: "agent", Env: []KubeEnvVar{{Name: "GOMEMLIMIT", Value: "10MiB"}}},
+		{Name: weaviateContainer, Env: []KubeEnvVar{{Name: "GOMEMLIMIT", Value: "2GiB"}}},
+	}}}}
+	local := map[string]string{"GOMEMLIMIT": "3GiB"}
+
+	assert.Equal(t, "1GiB", serverGetenv(hosts, docker, nil, local)("GOMEMLIMIT"))
+	assert.Equal(t, "2GiB", serverGetenv(hosts, nil, kube, local)("GOMEMLIMIT"))
+	assert.Equal(t, "3GiB", serverGetenv(hosts, nil, nil, local)("GOMEMLIMIT"))
+	// the collector's environment is never used
+	assert.Nil(t, serverGetenv(hosts, nil, nil, nil))
+	// the agents report every node's environment themselves
+	agentHosts := []ServerHost{{Name: "weaviate-0", Agent: &AgentInfo{}}}
+	assert.Nil(t, serverGetenv(agentHosts, docker, kube, local))
+}
+
+func TestPrintValidations(t *testing.T) {
+	var out bytes.Buffer
+	printValidations(&out, []Validation{
+		{Message: "1 of 2 hybrid queries on class Article failed: no &lt;vectorizer&gt;"},
+		{Message: "<code>/v1/meta</code> takes 2s (p95)", Hint: "Check the <code>GOMEMLIMIT</code> &amp; the network"},
+	})
+	assert.Equal(t, "1 of 2 hybrid queries on class Article failed: no <vectorizer>\n"+
+		"/v1/meta takes 2s (p95)\n"+
+		"  Check the GOMEMLIMIT & the network\n", out.String())
+}
+
+func TestHostInfo(t *testing.T) {
+	hostInfo := HostInfo{
+		MaxMapCount:          65530,
+		FilesystemType:       "nfs4",
+		TransparentHugepages: "madvise",
+		OpenFilesSoft:        1048576,
+	}
+	assumed := []Validation{
+		{
+			Message: "<code>vm.max_map_count</code> is set low: 65530. Weaviate memory maps every LSM segment, raise it to at least 262144",
+		},
+		{
+			Message: "The data directory is on a nfs4 filesystem, memory mapped files are slow or unreliable on it",
+		},
+	}
+	validations := validateHostInfo(hostInfo)
 	assert.Equal(t, assumed, validations)
 }
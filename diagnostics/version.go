@@ -0,0 +1,87 @@
+package diagnostics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseVersion parses the major, minor and patch number of a Weaviate version
+// such as "1.24.10" or "v1.25.0-rc.1". Missing or invalid parts are 0.
+func parseVersion(version string) [3]int {
+	var parsed [3]int
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	for i, part := range strings.SplitN(version, ".", 3) {
+		parsed[i], _ = strconv.Atoi(part)
+	}
+	return parsed
+}
+
+// compareVersions returns -1, 0 or 1 if version a is lower, equal or higher
+// than b.
+func compareVersions(a string, b string) int {
+	parsedA, parsedB := parseVersion(a), parseVersion(b)
+	for i := range parsedA {
+		switch {
+		case parsedA[i] < parsedB[i]:
+			return -1
+		case parsedA[i] > parsedB[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionOperators are the operators of version range constraints
+var versionOperators = map[string]bool{"<": true, "<=": true, ">": true, ">=": true, "=": true, "": true}
+
+func splitConstraint(constraint string) (string, string) {
+	operator := strings.TrimRight(constraint, "v0123456789.-+rc")
+	return operator, strings.TrimPrefix(constraint, operator)
+}
+
+// checkVersionRange returns an error if a constraint of the range has no
+// version or an operator versionInRange does not know, such as != or ~.
+func checkVersionRange(versionRange string) error {
+	for _, constraint := range strings.Fields(versionRange) {
+		operator, version := splitConstraint(constraint)
+		if !versionOperators[operator] {
+			return fmt.Errorf("unknown operator %q in version constraint %q", operator, constraint)
+		}
+		if version == "" {
+			return fmt.Errorf("version constraint %q has no version", constraint)
+		}
+	}
+	return nil
+}
+
+// versionInRange reports whether a version lies in a range of space separated
+// constraints such as ">=1.24.0 <1.25.2", which all have to match. The
+// operators are <, <=, >, >= and =, others never match and are rejected by
+// checkVersionRange. An empty range matches every version.
+func versionInRange(version string, versionRange string) bool {
+	for _, constraint := range strings.Fields(versionRange) {
+		operator, bound := splitConstraint(constraint)
+		cmp := compareVersions(version, bound)
+		var matches bool
+		switch operator {
+		case "<":
+			matches = cmp < 0
+		case "<=":
+			matches = cmp <= 0
+		case ">":
+			matches = cmp > 0
+		case ">=":
+			matches = cmp >= 0
+		case "=", "":
+			matches = cmp == 0
+		}
+		if !matches {
+			return false
+		}
+	}
+	return true
+}
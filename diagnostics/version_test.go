@@ -0,0 +1,25 @@
+package diagnostics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareVersions(t *testing.T) {
+	assert.Equal(t, [3]int{1, 25, 0}, parseVersion("v1.25.0-rc.1"))
+	assert.Equal(t, -1, compareVersions("1.24.10", "1.25.0"))
+	assert.Equal(t, 1, compareVersions("1.24.10", "1.24.9"))
+	assert.Equal(t, 0, compareVersions("1.25", "1.25.0"))
+}
+
+func TestVersionInRange(t *testing.T) {
+	assert.True(t, versionInRange("1.24.3", ""))
+	assert.True(t, versionInRange("1.24.3", ">=1.24.0 <1.25.2"))
+	assert.True(t, versionInRange("v1.25.1", ">=1.24.0 <1.25.2"))
+	assert.False(t, versionInRange("1.25.2", ">=1.24.0 <1.25.2"))
+	assert.False(t, versionInRange("1.23.9", ">=1.24.0 <1.25.2"))
+	assert.True(t, versionInRange("1.25.0", "=1.25.0"))
+	assert.True(t, versionInRange("1.25.0", "<=1.25"))
+	assert.False(t, versionInRange("1.25.0", ">1.25.0"))
+}
@@ -0,0 +1,242 @@
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/weaviate/weaviate-go-client/v4/weaviate"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate/data/replication"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+const (
+	// writeProbeClassPrefix names the temporary class of the write probe, so
+	// it cannot be mistaken for user data
+	writeProbeClassPrefix = "WeaviateDiagnosticsWriteProbe"
+	// writeProbeDimensions is the number of dimensions of the random vectors
+	writeProbeDimensions = 32
+	// maxWriteProbeReplicas caps the replication factor of the temporary class
+	maxWriteProbeReplicas = 3
+	// slowBatch is the p95 batch latency from which on writes are flagged
+	slowBatch = 2 * time.Second
+)
+
+// WriteProbeResult holds the latencies of the batches written with a
+// consistency level.
+type WriteProbeResult struct {
+	ConsistencyLevel string
+	Batches          int
+	Objects          int
+	Errors           int
+	// Error is the first error of a batch or an object
+	Error string
+	P50   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+}
+
+// WriteProbeReport is the outcome of importing synthetic objects into a
+// temporary class.
+type WriteProbeReport struct {
+	Class    string
+	Replicas int
+	Results  []WriteProbeResult
+	// Error is set if the class could not be created
+	Error string
+	// CleanupError is set if the class could not be deleted
+	CleanupError string
+}
+
+func writeProbeObjects(class string, count int, rng *rand.Rand) []*models.Object {
+	objects := make([]*models.Object, count)
+	for i := range objects {
+		vector := make([]float32, writeProbeDimensions)
+		for d := range vector {
+			vector[d] = rng.Float32()
+		}
+		objects[i] = &models.Object{
+			Class:      class,
+			Properties: map[string]interface{}{"text": fmt.Sprintf("write probe object %d", i)},
+			Vector:     vector,
+		}
+	}
+	return objects
+}
+
+// writeBatches imports the objects in batches of batchSize with the
+// consistency level until ctx is canceled.
+func writeBatches(ctx context.Context, client *weaviate.Client, objects []*models.Object, batchSize int, consistencyLevel string) WriteProbeResult {
+	result := WriteProbeResult{ConsistencyLevel: consistencyLevel}
+	setError := func(message string) {
+		if result.Error == "" {
+			result.Error = message
+		}
+	}
+
+	var durations []time.Duration
+	for start := 0; start < len(objects) && ctx.Err() == nil; start += batchSize {
+		batch := objects[start:min(start+batchSize, len(objects))]
+		result.Batches++
+		result.Objects += len(batch)
+
+		begin := time.Now()
+		resp, err := client.Batch().ObjectsBatcher().WithObjects(batch...).WithConsistencyLevel(consistencyLevel).Do(ctx)
+		duration := time.Since(begin)
+		if err != nil {
+			result.Errors += len(batch)
+			setError(err.Error())
+			continue
+		}
+		durations = append(durations, duration)
+		for _, object := range resp {
+			if object.Result != nil && object.Result.Errors != nil && len(object.Result.Errors.Error) > 0 {
+				result.Errors++
+				setError(object.Result.Errors.Error[0].Message)
+			}
+		}
+	}
+
+	sort.Slice(durations, func(a, b int) bool { return durations[a] < durations[b] })
+	result.P50 = percentile(durations, 50)
+	result.P95 = percentile(durations, 95)
+	result.P99 = percentile(durations, 99)
+	return result
+}
+
+// writeProbe creates a temporary class replicated to up to three of the
+// healthy nodes, imports count objects with every consistency level and
+// deletes the class again. If ctx is canceled, the import stops and the class
+// is deleted right away.
+func writeProbe(ctx context.Context, client *weaviate.Client, className string, nodes []*models.NodeStatus, count int, batchSize int) *WriteProbeReport {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	replicas := 0
+	for _, node := range nodes {
+		if node.Status == nil || *node.Status == nodeStatusHealthy {
+			replicas++
+		}
+	}
+	replicas = max(1, min(replicas, maxWriteProbeReplicas))
+
+	report := &WriteProbeReport{
+		Class:    className,
+		Replicas: replicas,
+	}
+	class := &models.Class{
+		Class:       report.Class,
+		Description: "Temporary class of the weaviate-diagnostics write probe, safe to delete",
+		Vectorizer:  "none",
+		Properties:  []*models.Property{{Name: "text", DataType: []string{"text"}}},
+		ReplicationConfig: &models.ReplicationConfig{
+			Factor: int64(replicas),
+		},
+	}
+	if err := client.Schema().ClassCreator().WithClass(class).Do(context.Background()); err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	defer func() {
+		if err := client.Schema().ClassDeleter().WithClassName(report.Class).Do(context.Background()); err != nil {
+			report.CleanupError = err.Error()
+		}
+	}()
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for _, consistencyLevel := range []string{replication.ConsistencyLevel.ONE, replication.ConsistencyLevel.QUORUM, replication.ConsistencyLevel.ALL} {
+		if ctx.Err() != nil {
+			break
+		}
+		objects := writeProbeObjects(report.Class, count, rng)
+		report.Results = append(report.Results, writeBatches(ctx, client, objects, batchSize, consistencyLevel))
+	}
+	return report
+}
+
+// runWriteProbe runs writeProbe with a new temporary class. On SIGINT or
+// SIGTERM it deletes the class and exits.
+func runWriteProbe(client *weaviate.Client, nodes []*models.NodeStatus, count int, batchSize int) *WriteProbeReport {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	className := fmt.Sprintf("%s%d", writeProbeClassPrefix, time.Now().Unix())
+	fmt.Printf("- Probing writes with the temporary class %s..\n", className)
+	report := writeProbe(ctx, client, className, nodes, count, batchSize)
+	if ctx.Err() != nil {
+		if report.CleanupError != "" {
+			log.Fatalf("Write probe interrupted, cannot delete its class %s: %s", className, report.CleanupError)
+		}
+		log.Fatalf("Write probe interrupted, its class %s was deleted", className)
+	}
+	return report
+}
+
+func printWriteProbe(w io.Writer, report *WriteProbeReport) {
+	fmt.Fprintf(w, "Class %s with %d replicas\n", report.Class, report.Replicas)
+	if report.Error != "" {
+		fmt.Fprintf(w, "Cannot create class: %s\n", report.Error)
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONSISTENCY\tBATCHES\tOBJECTS\tERRORS\tP50\tP95\tP99\tERROR")
+	for _, result := range report.Results {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\t%s\t%s\t%s\n", result.ConsistencyLevel, result.Batches, result.Objects, result.Errors,
+			result.P50.Round(time.Microsecond), result.P95.Round(time.Microsecond), result.P99.Round(time.Microsecond), result.Error)
+	}
+	tw.Flush()
+}
+
+func validateWriteProbe(report *WriteProbeReport) []Validation {
+	var validations []Validation
+	if report == nil {
+		return validations
+	}
+
+	if report.Error != "" {
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("The write probe cannot create the class %s: %s", report.Class, html.EscapeString(report.Error)),
+			Hint:    "Check that the API key or user may create classes and that the schema is not locked by a node which is down",
+		})
+	}
+
+	for _, result := range report.Results {
+		if result.Errors > 0 {
+			validation := Validation{
+				Message: fmt.Sprintf("%d of %d objects written with consistency level %s failed: %s",
+					result.Errors, result.Objects, result.ConsistencyLevel, html.EscapeString(result.Error)),
+			}
+			switch result.ConsistencyLevel {
+			case replication.ConsistencyLevel.QUORUM:
+				validation.Hint = fmt.Sprintf("Writes with QUORUM need a majority of the %d replicas, check for nodes which are down or unreachable", report.Replicas)
+			case replication.ConsistencyLevel.ALL:
+				validation.Hint = fmt.Sprintf("Writes with ALL need all %d replicas, check for nodes which are down or unreachable", report.Replicas)
+			}
+			validations = append(validations, validation)
+		}
+		if result.P95 > slowBatch {
+			validations = append(validations, Validation{
+				Message: fmt.Sprintf("Batches written with consistency level %s take %s (p95)", result.ConsistencyLevel, result.P95.Round(time.Millisecond)),
+				Hint:    "Check the CPU and disk usage of the nodes and the vector index queues, slow writes often come from indexing",
+			})
+		}
+	}
+
+	if report.CleanupError != "" {
+		validations = append(validations, Validation{
+			Message: fmt.Sprintf("The write probe cannot delete its class %s: %s. Delete it manually", report.Class, html.EscapeString(report.CleanupError)),
+		})
+	}
+
+	return validations
+}
@@ -0,0 +1,109 @@
+package diagnostics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+func TestWriteProbe(t *testing.T) {
+	var created *models.Class
+	var deleted string
+	batches := map[string]int{}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/schema":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+			w.Write([]byte(`{}`))
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/v1/schema/"):
+			deleted = strings.TrimPrefix(r.URL.Path, "/v1/schema/")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": [{"message": "schema is read only"}]}`))
+		case r.URL.Path == "/v1/batch/objects":
+			var body struct {
+				Objects []*models.Object `json:"objects"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			level := r.URL.Query().Get("consistency_level")
+			batches[level]++
+			var resp []string
+			for i, object := range body.Objects {
+				assert.Equal(t, created.Class, object.Class)
+				assert.Len(t, object.Vector, writeProbeDimensions)
+				// the second object of every ALL batch misses a replica
+				if level == "ALL" && i == 1 {
+					resp = append(resp, `{"result": {"errors": {"error": [{"message": "cannot achieve consistency level ALL"}]}}}`)
+				} else {
+					resp = append(resp, `{"result": {}}`)
+				}
+			}
+			fmt.Fprintf(w, "[%s]", strings.Join(resp, ","))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	healthy, unhealthy := nodeStatusHealthy, "UNHEALTHY"
+	nodes := []*models.NodeStatus{{Status: &healthy}, {Status: &healthy}, {Status: &unhealthy}}
+	report := writeProbe(context.Background(), client, writeProbeClassPrefix+"1700000000", nodes, 25, 10)
+
+	require.NotNil(t, created)
+	assert.Equal(t, writeProbeClassPrefix+"1700000000", created.Class)
+	assert.Equal(t, int64(2), created.ReplicationConfig.Factor)
+	assert.Equal(t, created.Class, deleted)
+	assert.Equal(t, map[string]int{"ONE": 3, "QUORUM": 3, "ALL": 3}, batches)
+
+	assert.Equal(t, created.Class, report.Class)
+	assert.Equal(t, 2, report.Replicas)
+	require.Len(t, report.Results, 3)
+	for _, result := range report.Results {
+		assert.Equal(t, 3, result.Batches, result.ConsistencyLevel)
+		assert.Equal(t, 25, result.Objects, result.ConsistencyLevel)
+		assert.NotZero(t, result.P99, result.ConsistencyLevel)
+	}
+	assert.Zero(t, report.Results[1].Errors)
+	assert.Equal(t, 3, report.Results[2].Errors)
+	assert.Equal(t, "cannot achieve consistency level ALL", report.Results[2].Error)
+	assert.Contains(t, report.CleanupError, "schema is read only")
+
+	validations := validateWriteProbe(report)
+	require.Len(t, validations, 2)
+	assert.Equal(t, Validation{
+		Message: "3 of 25 objects written with consistency level ALL failed: cannot achieve consistency level ALL",
+		Hint:    "Writes with ALL need all 2 replicas, check for nodes which are down or unreachable",
+	}, validations[0])
+	assert.True(t, strings.HasPrefix(validations[1].Message, "The write probe cannot delete its class "+report.Class))
+
+	var out bytes.Buffer
+	printWriteProbe(&out, report)
+	assert.Contains(t, out.String(), "CONSISTENCY  BATCHES")
+	assert.Empty(t, validateWriteProbe(nil))
+
+	// errors are rendered as HTML
+	assert.Equal(t, "The write probe cannot create the class "+report.Class+": unexpected &lt;html&gt; response",
+		validateWriteProbe(&WriteProbeReport{Class: report.Class, Error: "unexpected <html> response"})[0].Message)
+}
+
+func TestWriteProbeCanceled(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Write([]byte(`{}`))
+	})
+
+	// the class is deleted without writing once the probe is canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report := writeProbe(ctx, client, writeProbeClassPrefix+"1700000000", nil, 25, 10)
+	assert.Empty(t, report.Results)
+	assert.Empty(t, report.CleanupError)
+	assert.Equal(t, []string{"POST /v1/schema", "DELETE /v1/schema/" + writeProbeClassPrefix + "1700000000"}, requests)
+}
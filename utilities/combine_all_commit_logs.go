@@ -0,0 +1,261 @@
+package utilities
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// commitLogDir is an HNSW commit log directory found below the data root.
+type commitLogDir struct {
+	Path  string
+	Class string
+	Shard string
+	Name  string
+	Files int
+	Size  int64
+}
+
+func (d commitLogDir) String() string {
+	return filepath.Join(d.Class, d.Shard, d.Name)
+}
+
+type combineResult struct {
+	Dir        commitLogDir
+	FilesAfter int
+	SizeAfter  int64
+	Duration   time.Duration
+	Err        error
+}
+
+const commitLogDirSuffix = ".hnsw.commitlog.d"
+
+// findCommitLogDirs walks a Weaviate data root and returns every vector index
+// commit log directory with its file count and size. Working directories of
+// an earlier combine as well as backups are skipped.
+func findCommitLogDirs(root string) ([]commitLogDir, error) {
+	var dirs []commitLogDir
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || path == root {
+			return nil
+		}
+		if d.Name() == "lsm" {
+			// object and inverted index buckets, nothing to find in here
+			return filepath.SkipDir
+		}
+		if !strings.Contains(d.Name(), commitLogDirSuffix) {
+			return nil
+		}
+
+		name := strings.TrimSuffix(d.Name(), commitLogDirSuffix)
+		if !strings.HasSuffix(d.Name(), commitLogDirSuffix) || strings.HasSuffix(name, "working") {
+			return filepath.SkipDir
+		}
+
+		dir := commitLogDir{Path: path, Name: name}
+		rel, err := filepath.Rel(root, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		if rel != "." {
+			dir.Shard = filepath.Base(rel)
+			if parent := filepath.Dir(rel); parent != "." {
+				dir.Class = parent
+			}
+		}
+
+		dir.Files, dir.Size, err = commitLogDirUsage(path)
+		if err != nil {
+			return err
+		}
+
+		dirs = append(dirs, dir)
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dirs, nil
+}
+
+func commitLogDirUsage(path string) (int, int64, error) {
+	logs, _, err := listCommitLogs(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var size int64
+	for _, name := range logs {
+		info, err := os.Stat(filepath.Join(path, name))
+		if err != nil {
+			return 0, 0, err
+		}
+		size += info.Size()
+	}
+
+	return len(logs), size, nil
+}
+
+// selectCommitLogDirs ranks the directories by file count and size and
+// returns those that are over either threshold. A minSize of 0 disables the
+// size threshold.
+func selectCommitLogDirs(dirs []commitLogDir, minFiles int, minSize int64) []commitLogDir {
+	sort.SliceStable(dirs, func(a, b int) bool {
+		if dirs[a].Files == dirs[b].Files {
+			return dirs[a].Size > dirs[b].Size
+		}
+		return dirs[a].Files > dirs[b].Files
+	})
+
+	var selected []commitLogDir
+	for _, dir := range dirs {
+		if dir.Files >= minFiles || (minSize > 0 && dir.Size >= minSize) {
+			selected = append(selected, dir)
+		}
+	}
+
+	return selected
+}
+
+func combineAllCommitLogs(root string) {
+	log.WithField("path", root).Info("Searching data root for commit logs")
+
+	dirs, err := findCommitLogDirs(root)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to search data root")
+	}
+
+	selected := selectCommitLogDirs(dirs, combineConfig.MinFiles, combineConfig.MinSizeMB*1024*1024)
+	log.Infof("Found %d commit log directories, %d over the threshold", len(dirs), len(selected))
+
+	printCommitLogDirs(selected)
+	if combineConfig.DryRun || len(selected) == 0 {
+		return
+	}
+
+	err = checkSafeToModify(root, combineConfig.WeaviateUrl, combineConfig.ApiKey, combineConfig.Node, combineConfig.Force)
+	if err != nil {
+		log.WithError(err).Fatal("Safety check failed")
+	}
+
+	for _, dir := range selected {
+		err = createSentinelFile(dir.Path)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to create sentinel file")
+		}
+	}
+
+	log.Infof("wait %s in case something is still in progress", combineConfig.Wait)
+	time.Sleep(combineConfig.Wait)
+
+	progress := newProgress("combine-commit-logs")
+	// the bytes and files are added by every combine once it knows which
+	// files it copies
+	progress.addTotal(0, 0, len(selected))
+	progress.start(combineConfig.ProgressInterval)
+	results := runCombines(selected, combineConfig.Parallel, progress)
+	progress.finish()
+
+	printCombineResults(results)
+	for _, result := range results {
+		if result.Err != nil {
+			log.Fatal("Some commit logs could not be combined, the disabled sentinel was left in place for those")
+		}
+	}
+}
+
+// runCombines combines the given directories with at most parallel combines
+// running at the same time.
+func runCombines(dirs []commitLogDir, parallel int, progress *progress) []combineResult {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	results := make([]combineResult, len(dirs))
+	sem := make(chan struct{}, parallel)
+	wg := sync.WaitGroup{}
+	var mu sync.Mutex
+	done := 0
+
+	for i, dir := range dirs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, dir commitLogDir) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			log.WithField("shard", dir.String()).Info("Combining commit logs")
+			started := time.Now()
+			result := combineResult{Dir: dir}
+			result.Err = combineCommitLogs(filepath.Dir(dir.Path), dir.Name, progress)
+			result.Duration = time.Since(started)
+			if result.Err == nil {
+				result.FilesAfter, result.SizeAfter, result.Err = commitLogDirUsage(dir.Path)
+			}
+			results[i] = result
+			progress.shardDone()
+
+			mu.Lock()
+			done++
+			entry := log.WithFields(log.Fields{
+				"shard":    dir.String(),
+				"progress": fmt.Sprintf("%d/%d", done, len(dirs)),
+				"took":     result.Duration.Round(time.Second),
+			})
+			mu.Unlock()
+			if result.Err != nil {
+				entry.WithError(result.Err).Error("Failed to combine commit logs")
+			} else {
+				entry.Infof("Combined %d files into %d", dir.Files, result.FilesAfter)
+			}
+		}(i, dir)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func printCommitLogDirs(dirs []commitLogDir) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "shard\tfiles\tsize\t")
+	for _, dir := range dirs {
+		fmt.Fprintf(w, "%s\t%d\t%s\t\n", dir, dir.Files, FormatBytes(dir.Size))
+	}
+	w.Flush()
+}
+
+func printCombineResults(results []combineResult) {
+	var failed int
+	var before, after int64
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "shard\tfiles before\tfiles after\tsize before\tsize after\ttook\tresult\t")
+	for _, result := range results {
+		status := "ok"
+		if result.Err != nil {
+			status = result.Err.Error()
+			failed++
+		}
+		before += result.Dir.Size
+		after += result.SizeAfter
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t\n", result.Dir, result.Dir.Files, result.FilesAfter,
+			FormatBytes(result.Dir.Size), FormatBytes(result.SizeAfter), result.Duration.Round(time.Second), status)
+	}
+	w.Flush()
+
+	fmt.Printf("\nCombined %d of %d shards, %s before, %s after\n",
+		len(results)-failed, len(results), FormatBytes(before), FormatBytes(after))
+}
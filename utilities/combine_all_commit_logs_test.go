@@ -0,0 +1,50 @@
+package utilities
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindAndSelectCommitLogDirs(t *testing.T) {
+	root := t.TempDir()
+
+	shards := map[string]int{
+		"article/shard-a/main.hnsw.commitlog.d":         3,
+		"article/shard-b/main.hnsw.commitlog.d":         5,
+		"article/shard-b/main.hnsw.commitlog.d.1.bak":   5,
+		"article/shard-b/main_working.hnsw.commitlog.d": 5,
+		"tenants/tenant1/main.hnsw.commitlog.d":         1,
+	}
+	for dir, files := range shards {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), os.ModePerm))
+		for i := 0; i < files; i++ {
+			name := filepath.Join(root, dir, fmt.Sprintf("%d", 1700000000+i))
+			require.NoError(t, os.WriteFile(name, make([]byte, 10), 0o644))
+		}
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "article/shard-a/lsm/objects"), os.ModePerm))
+
+	dirs, err := findCommitLogDirs(root)
+	require.NoError(t, err)
+	require.Len(t, dirs, 3)
+
+	selected := selectCommitLogDirs(dirs, 3, 0)
+	require.Len(t, selected, 2)
+	assert.Equal(t, commitLogDir{
+		Path:  filepath.Join(root, "article/shard-b/main.hnsw.commitlog.d"),
+		Class: "article",
+		Shard: "shard-b",
+		Name:  "main",
+		Files: 5,
+		Size:  50,
+	}, selected[0])
+	assert.Equal(t, "shard-a", selected[1].Shard)
+
+	selected = selectCommitLogDirs(dirs, 100, 10)
+	assert.Len(t, selected, 3)
+}
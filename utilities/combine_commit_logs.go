@@ -25,10 +25,13 @@ var combineCommitLogCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		log.Info("Running commit log combiner")
 		basePath := filepath.Clean(args[0])
+
+		if combineConfig.All {
+			combineAllCommitLogs(basePath)
+			return
+		}
+
 		name := "main"
-		targetThreshold := 1024 * 1024 * 24000 // 24GiB
-		dontTouchLastFiles := 10
-		totalFileLimit := 2000
 		commitLogPath := fmt.Sprintf("%s/%s.hnsw.commitlog.d", basePath, name)
 
 		log.WithField("path", basePath).Info("Path value")
@@ -39,140 +42,195 @@ var combineCommitLogCmd = &cobra.Command{
 			log.WithError(err).Fatal("Path validation failed")
 		}
 
+		err = checkSafeToModify(commitLogPath, combineConfig.WeaviateUrl, combineConfig.ApiKey, combineConfig.Node, combineConfig.Force)
+		if err != nil {
+			log.WithError(err).Fatal("Safety check failed")
+		}
+
 		err = createSentinelFile(commitLogPath)
 		if err != nil {
 			log.WithError(err).Fatal("Failed to create sentinel file")
 		}
 
-		log.Info("wait 120s in case something is still in progress")
-		time.Sleep(120 * time.Second)
-
-		workingName := "working"
-		workingPath := filepath.Join(basePath, fmt.Sprintf("%s.hnsw.commitlog.d", workingName))
-		backupPath := filepath.Join(basePath, fmt.Sprintf("main.hnsw.commitlog.d.%d.bak", time.Now().Unix()))
+		log.Infof("wait %s in case something is still in progress", combineConfig.Wait)
+		time.Sleep(combineConfig.Wait)
 
-		err = os.MkdirAll(workingPath, os.ModePerm)
-		if err != nil {
-			log.WithError(err).Fatal("Failed to create working folder")
-		}
-		err = os.MkdirAll(backupPath, os.ModePerm)
+		progress := newProgress("combine-commit-logs")
+		progress.start(combineConfig.ProgressInterval)
+		err = combineCommitLogs(basePath, name, progress)
+		progress.finish()
 		if err != nil {
-			log.WithError(err).Fatal("Failed to create backup folder")
+			log.WithError(err).Fatal("Failed to combine commit logs")
 		}
+	},
+}
 
-		selectedFiles, err := selectCommitLogs(commitLogPath, dontTouchLastFiles, totalFileLimit)
-		if err != nil {
-			log.WithError(err).Fatal("Failed to select commit logs")
-		}
+type CombineConfig struct {
+	Force       bool
+	WeaviateUrl string
+	ApiKey      string
+	Node        string
+	Wait        time.Duration
+	All         bool
+	Parallel    int
+	MinFiles    int
+	MinSizeMB   int64
+	DryRun      bool
+
+	ProgressInterval time.Duration
+}
 
-		log.Infof("start copying into working path: %s", workingPath)
-		err = copyCommitLogs(selectedFiles, commitLogPath, workingPath)
-		if err != nil {
-			log.WithError(err).Fatal("Failed to copy commit logs into working dir")
-		}
+var combineConfig CombineConfig
 
-		log.Infof("start copying into backup path: %s", backupPath)
-		err = copyCommitLogs(selectedFiles, commitLogPath, backupPath)
-		if err != nil {
-			log.WithError(err).Fatal("Failed to copy commit logs into backup")
-		}
+func NewCombineCommitLogCmd() *cobra.Command {
+	combineCommitLogCmd.PersistentFlags().BoolVarP(&combineConfig.Force,
+		"force", "f", false, "Run even if the commit logs appear to be in use")
 
-		logger := log.New()
-		commitLogger, err := hnsw.NewCommitLogger(basePath, workingName, logger,
-			cyclemanager.NewCallbackGroupNoop(),
-			hnsw.WithCommitlogThresholdForCombining(int64(targetThreshold)),
-			hnsw.WithCommitlogThreshold(int64(targetThreshold/5)))
-		if err != nil {
-			log.WithError(err).Fatal("Failed to create commit logger")
-		}
+	combineCommitLogCmd.PersistentFlags().StringVarP(&combineConfig.WeaviateUrl,
+		"url", "u", "", "URL of the Weaviate cluster to confirm the shards of the node are read-only")
 
-		i := 0
-		for {
-			var ok1 bool
-			var ok2 bool
-			var err error
-
-			ok := true
-			for ok {
-				ok, err = commitLogger.CombineLogs()
-				if ok {
-					ok1 = true
-				}
-				if err != nil {
-					log.WithError(err).Fatal("Failed to combine commit logs")
-				}
-			}
+	combineCommitLogCmd.PersistentFlags().StringVarP(&combineConfig.ApiKey,
+		"apiKey", "a", "", "API key authentication")
 
-			ok = true
-			for ok {
-				ok, err = commitLogger.CondenseOldLogs()
-				if ok {
-					ok2 = true
-				}
-				if err != nil {
-					log.WithError(err).Fatal("Failed to condense commit logs")
-				}
-			}
+	combineCommitLogCmd.PersistentFlags().StringVar(&combineConfig.Node,
+		"node", "", "Name of the Weaviate node owning <path>, required with --url on multi-node clusters")
 
-			i++
-			ok = ok1 || ok2
-			if !ok {
-				// never entered either loop, we are done!
-				log.Infof("completing combine and condense loop after %d iterations", i)
-				break
-			}
-		}
+	combineCommitLogCmd.PersistentFlags().DurationVar(&combineConfig.Wait,
+		"wait", 120*time.Second, "Time to wait after disabling the commit logs in case something is still in progress")
 
-		err = commitLogger.Flush()
-		if err != nil {
-			log.WithError(err).Fatal("Failed to flush commit logger")
-		}
+	combineCommitLogCmd.PersistentFlags().BoolVar(&combineConfig.All,
+		"all", false, "Treat <path> as the Weaviate data root and combine the commit logs of every shard")
 
-		err = commitLogger.Shutdown(context.Background())
-		if err != nil {
-			log.WithError(err).Fatal("Failed to shutdown commit logger")
-		}
+	combineCommitLogCmd.PersistentFlags().IntVar(&combineConfig.Parallel,
+		"parallel", 2, "Number of shards to combine at the same time with --all")
 
-		// Remove the selected files
-		for _, file := range selectedFiles {
-			filePath := filepath.Join(commitLogPath, file)
-			err = os.Remove(filePath)
-			if err != nil {
-				log.WithError(err).WithField("file", file).Fatal("Failed to remove file")
-			}
-			log.WithField("file", file).Info("Removed commit log")
-		}
+	combineCommitLogCmd.PersistentFlags().IntVar(&combineConfig.MinFiles,
+		"min-files", 100, "Only combine commit log directories with at least this many files with --all")
 
-		// Copy the combined files to the main folder
-		var combinedFiles []string
-		files, err := os.ReadDir(workingPath)
-		if err != nil {
-			log.WithError(err).Fatal("Failed to read directory")
-		}
-		for _, file := range files {
-			combinedFiles = append(combinedFiles, file.Name())
-		}
+	combineCommitLogCmd.PersistentFlags().Int64Var(&combineConfig.MinSizeMB,
+		"min-size-mb", 0, "Also combine commit log directories larger than this size with --all, 0 to disable")
 
-		err = copyCommitLogs(combinedFiles, workingPath, commitLogPath)
-		if err != nil {
-			log.WithError(err).Fatal("Failed to copy new combined commit logs")
-		}
+	combineCommitLogCmd.PersistentFlags().BoolVar(&combineConfig.DryRun,
+		"dry-run", false, "Only list the commit log directories that would be combined with --all")
 
-		// // Remove the working folder
-		err = os.RemoveAll(workingPath)
-		if err != nil {
-			log.WithError(err).Fatal("Failed to remove working folder")
-		}
+	combineCommitLogCmd.PersistentFlags().DurationVar(&combineConfig.ProgressInterval,
+		"progress-interval", 5*time.Second, "How often to report progress")
+
+	return combineCommitLogCmd
+}
+
+// combineCommitLogs combines and condenses the commit logs of a single vector
+// index. The disabled sentinel must already be in place, it is removed once
+// the combined logs have been moved back. Progress may be nil.
+func combineCommitLogs(basePath string, name string, progress *progress) error {
+	targetThreshold := 1024 * 1024 * 24000 // 24GiB
+	dontTouchLastFiles := 10
+	totalFileLimit := 2000
+	commitLogPath := filepath.Join(basePath, fmt.Sprintf("%s.hnsw.commitlog.d", name))
+
+	workingName := fmt.Sprintf("%s_working", name)
+	workingPath := filepath.Join(basePath, fmt.Sprintf("%s.hnsw.commitlog.d", workingName))
+	backupPath := filepath.Join(basePath, fmt.Sprintf("%s.hnsw.commitlog.d.%d.bak", name, time.Now().Unix()))
+
+	err := os.MkdirAll(workingPath, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("failed to create working folder: %w", err)
+	}
+	err = os.MkdirAll(backupPath, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("failed to create backup folder: %w", err)
+	}
+
+	selectedFiles, err := selectCommitLogs(commitLogPath, dontTouchLastFiles, totalFileLimit)
+	if err != nil {
+		return fmt.Errorf("failed to select commit logs: %w", err)
+	}
+
+	// the selected files are copied into the working and the backup folder
+	selectedSize, err := commitLogsSize(commitLogPath, selectedFiles)
+	if err != nil {
+		return err
+	}
+	progress.addTotal(2*selectedSize, 2*len(selectedFiles), 0)
+
+	log.WithField("path", commitLogPath).Infof("start copying into working path: %s", workingPath)
+	err = copyCommitLogs(selectedFiles, commitLogPath, workingPath, progress)
+	if err != nil {
+		return fmt.Errorf("failed to copy commit logs into working dir: %w", err)
+	}
+
+	log.WithField("path", commitLogPath).Infof("start copying into backup path: %s", backupPath)
+	err = copyCommitLogs(selectedFiles, commitLogPath, backupPath, progress)
+	if err != nil {
+		return fmt.Errorf("failed to copy commit logs into backup: %w", err)
+	}
+
+	logger := log.New()
+	commitLogger, err := hnsw.NewCommitLogger(basePath, workingName, logger,
+		cyclemanager.NewCallbackGroupNoop(),
+		hnsw.WithCommitlogThresholdForCombining(int64(targetThreshold)),
+		hnsw.WithCommitlogThreshold(int64(targetThreshold/5)))
+	if err != nil {
+		return fmt.Errorf("failed to create commit logger: %w", err)
+	}
+
+	err = combineAndCondense(commitLogger, commitLogPath, progress)
+	if err != nil {
+		return err
+	}
 
-		err = removeSentinelFile(commitLogPath)
+	err = commitLogger.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to flush commit logger: %w", err)
+	}
+
+	err = commitLogger.Shutdown(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to shutdown commit logger: %w", err)
+	}
+
+	// Remove the selected files
+	for _, file := range selectedFiles {
+		filePath := filepath.Join(commitLogPath, file)
+		err = os.Remove(filePath)
 		if err != nil {
-			log.WithError(err).Fatal("Failed to remove sentinel file")
+			return fmt.Errorf("failed to remove file %s: %w", file, err)
 		}
-	},
-}
+		log.WithField("file", filePath).Debug("Removed commit log")
+	}
 
-func NewCombineCommitLogCmd() *cobra.Command {
-	return combineCommitLogCmd
+	// Copy the combined files to the main folder
+	var combinedFiles []string
+	files, err := os.ReadDir(workingPath)
+	if err != nil {
+		return fmt.Errorf("failed to read directory: %w", err)
+	}
+	for _, file := range files {
+		combinedFiles = append(combinedFiles, file.Name())
+	}
+	combinedSize, err := commitLogsSize(workingPath, combinedFiles)
+	if err != nil {
+		return err
+	}
+	progress.addTotal(combinedSize, len(combinedFiles), 0)
+
+	err = copyCommitLogs(combinedFiles, workingPath, commitLogPath, progress)
+	if err != nil {
+		return fmt.Errorf("failed to copy new combined commit logs: %w", err)
+	}
+
+	// Remove the working folder
+	err = os.RemoveAll(workingPath)
+	if err != nil {
+		return fmt.Errorf("failed to remove working folder: %w", err)
+	}
+
+	err = removeSentinelFile(commitLogPath)
+	if err != nil {
+		return fmt.Errorf("failed to remove sentinel file: %w", err)
+	}
+
+	return nil
 }
 
 func validatePath(path string) error {
@@ -188,46 +246,121 @@ func validatePath(path string) error {
 		return fmt.Errorf("path must be a folder: %s", path)
 	}
 
-	// Check if the path ends with "main.hnsw.commitlog.d/"
-	if !strings.HasSuffix(filepath.ToSlash(path), "main.hnsw.commitlog.d") {
-		return fmt.Errorf("path must end with 'main.hnsw.commitlog.d'")
+	// Check if the path ends with ".hnsw.commitlog.d/"
+	if !strings.HasSuffix(filepath.ToSlash(path), ".hnsw.commitlog.d") {
+		return fmt.Errorf("path must end with '.hnsw.commitlog.d'")
 	}
 
 	return nil
 }
 
-func copyCommitLogs(selectedFiles []string, basePath string, workingPath string) error {
-	// Copy the selected files to the new folder
-	for _, file := range selectedFiles {
-		srcPath := filepath.Join(basePath, file)
-		dstPath := filepath.Join(workingPath, file)
+// logCombiner is the part of the HNSW commit logger that combines and
+// condenses logs.
+type logCombiner interface {
+	CombineLogs() (bool, error)
+	CondenseOldLogs() (bool, error)
+}
 
-		src, err := os.Open(srcPath)
-		if err != nil {
-			log.WithError(err).WithField("file", file).Panic("Failed to open source file")
-			continue
+// combineAndCondense combines and condenses the logs until neither changes
+// anything anymore. This is the long running part of a combine, its progress
+// is reported in iterations and elapsed time.
+func combineAndCondense(commitLogger logCombiner, commitLogPath string, progress *progress) error {
+	progress.combineStart()
+	defer progress.combineDone()
+
+	i := 0
+	for {
+		var ok1 bool
+		var ok2 bool
+		var err error
+
+		ok := true
+		for ok {
+			ok, err = commitLogger.CombineLogs()
+			if ok {
+				ok1 = true
+			}
+			if err != nil {
+				return fmt.Errorf("failed to combine commit logs: %w", err)
+			}
+		}
+
+		ok = true
+		for ok {
+			ok, err = commitLogger.CondenseOldLogs()
+			if ok {
+				ok2 = true
+			}
+			if err != nil {
+				return fmt.Errorf("failed to condense commit logs: %w", err)
+			}
 		}
-		defer src.Close()
 
-		dst, err := os.Create(dstPath)
+		i++
+		progress.iterationDone()
+		ok = ok1 || ok2
+		if !ok {
+			// never entered either loop, we are done!
+			log.WithField("path", commitLogPath).Infof("completing combine and condense loop after %d iterations", i)
+			break
+		}
+	}
+
+	return nil
+}
+
+// commitLogsSize returns the total size of the given files of a directory.
+func commitLogsSize(path string, files []string) (int64, error) {
+	var size int64
+	for _, file := range files {
+		info, err := os.Stat(filepath.Join(path, file))
 		if err != nil {
-			log.WithError(err).WithField("file", file).Panic("Failed to create destination file")
-			continue
+			return 0, fmt.Errorf("failed to stat %s: %w", file, err)
 		}
-		defer dst.Close()
+		size += info.Size()
+	}
+	return size, nil
+}
 
-		_, err = io.Copy(dst, src)
+func copyCommitLogs(selectedFiles []string, basePath string, workingPath string, progress *progress) error {
+	// Copy the selected files to the new folder
+	for _, file := range selectedFiles {
+		err := copyFile(filepath.Join(basePath, file), filepath.Join(workingPath, file), progress)
 		if err != nil {
-			log.WithError(err).WithField("file", file).Panic("Failed to copy file")
-			continue
+			return fmt.Errorf("failed to copy %s: %w", file, err)
 		}
 
-		log.WithField("file", file).Info("Copied commit log")
+		progress.fileDone()
+		log.WithField("file", filepath.Join(workingPath, file)).Debug("Copied commit log")
 	}
 
 	return nil
 }
 
+func copyFile(srcPath string, dstPath string, progress *progress) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = dst
+	if progress != nil {
+		w = progressWriter{w: dst, progress: progress}
+	}
+	if _, err := io.Copy(w, src); err != nil {
+		dst.Close()
+		return err
+	}
+
+	return dst.Close()
+}
+
 func selectCommitLogs(path string, dontTouchLastFiles int, totalLimit int) ([]string, error) {
 	files, err := os.ReadDir(path)
 	if err != nil {
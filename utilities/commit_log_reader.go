@@ -0,0 +1,185 @@
+package utilities
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/weaviate/weaviate/adapters/repos/db/vector/hnsw"
+)
+
+// commitLogRecord is a single operation read from an HNSW commit log. Only the
+// fields relevant to the record type are set.
+type commitLogRecord struct {
+	Type   hnsw.HnswCommitType
+	Offset int64
+	Size   int64
+	Node   uint64
+	Level  uint16
+	Links  int
+}
+
+// countingReader keeps track of how many bytes have been consumed so records
+// can be reported with their byte offset in the file.
+type countingReader struct {
+	r      *bufio.Reader
+	offset int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.offset += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.offset++
+	}
+	return b, err
+}
+
+// readCommitLog reads every record of an HNSW commit log and calls fn for each
+// one. It returns the length of the valid prefix of the log. If the log ends
+// in the middle of a record or contains an unknown record type, an error is
+// returned together with the offset of the last complete record.
+func readCommitLog(r io.Reader, fn func(commitLogRecord) error) (int64, error) {
+	cr := &countingReader{r: bufio.NewReaderSize(r, 1024*1024)}
+	deserializer := hnsw.NewDeserializer(log.New())
+	buf := make([]byte, 12)
+
+	var validLength int64
+	for {
+		start := cr.offset
+		ct, err := cr.ReadByte()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return validLength, nil
+			}
+			return validLength, err
+		}
+
+		record := commitLogRecord{Type: hnsw.HnswCommitType(ct), Offset: start}
+		switch record.Type {
+		case hnsw.AddNode, hnsw.SetEntryPointMaxLevel, hnsw.ClearLinksAtLevel:
+			if _, err = io.ReadFull(cr, buf[:10]); err == nil {
+				record.Node = binary.LittleEndian.Uint64(buf[0:8])
+				record.Level = binary.LittleEndian.Uint16(buf[8:10])
+			}
+		case hnsw.AddLinkAtLevel:
+			if _, err = io.ReadFull(cr, buf[:10]); err == nil {
+				record.Node = binary.LittleEndian.Uint64(buf[0:8])
+				record.Level = binary.LittleEndian.Uint16(buf[8:10])
+				record.Links = 1
+				_, err = io.ReadFull(cr, buf[:8])
+			}
+		case hnsw.AddLinksAtLevel, hnsw.ReplaceLinksAtLevel:
+			if _, err = io.ReadFull(cr, buf[:12]); err == nil {
+				record.Node = binary.LittleEndian.Uint64(buf[0:8])
+				record.Level = binary.LittleEndian.Uint16(buf[8:10])
+				record.Links = int(binary.LittleEndian.Uint16(buf[10:12]))
+				_, err = io.CopyN(io.Discard, cr, int64(record.Links)*8)
+			}
+		case hnsw.AddTombstone, hnsw.RemoveTombstone, hnsw.ClearLinks, hnsw.DeleteNode:
+			if _, err = io.ReadFull(cr, buf[:8]); err == nil {
+				record.Node = binary.LittleEndian.Uint64(buf[0:8])
+			}
+		case hnsw.ResetIndex:
+		case hnsw.AddPQ:
+			// the size of the PQ data depends on the encoder, let weaviate parse it
+			err = deserializer.ReadPQ(cr, &hnsw.DeserializationResult{})
+		default:
+			err = fmt.Errorf("unrecognized commit type %d", ct)
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return validLength, fmt.Errorf("record %s at offset %d: %w", record.Type, start, err)
+		}
+
+		record.Size = cr.offset - start
+		validLength = cr.offset
+		if err := fn(record); err != nil {
+			return validLength, err
+		}
+	}
+}
+
+// readCommitLogFile is a convenience wrapper around readCommitLog for files
+// on disk.
+func readCommitLogFile(path string, fn func(commitLogRecord) error) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	return readCommitLog(file, fn)
+}
+
+// commitLogTimestamp parses the unix timestamp weaviate uses as the name of a
+// commit log file, with or without the ".condensed" suffix.
+func commitLogTimestamp(name string) (int64, error) {
+	return strconv.ParseInt(strings.TrimSuffix(name, ".condensed"), 10, 64)
+}
+
+// listCommitLogs returns the commit log files of a directory in the order
+// weaviate would read them, as well as any other files found.
+func listCommitLogs(path string) ([]string, []string, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var logs, other []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if _, err := commitLogTimestamp(entry.Name()); err != nil {
+			other = append(other, entry.Name())
+			continue
+		}
+		logs = append(logs, entry.Name())
+	}
+
+	sort.Slice(logs, func(a, b int) bool {
+		tsA, _ := commitLogTimestamp(logs[a])
+		tsB, _ := commitLogTimestamp(logs[b])
+		if tsA == tsB {
+			return logs[a] < logs[b]
+		}
+		return tsA < tsB
+	})
+
+	return logs, other, nil
+}
+
+// resolveCommitLogs accepts either a single commit log file or a commit log
+// directory and returns the directory and the files in it to read.
+func resolveCommitLogs(path string) (string, []string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !info.IsDir() {
+		return filepath.Dir(path), []string{filepath.Base(path)}, nil
+	}
+
+	logs, _, err := listCommitLogs(path)
+	if err != nil {
+		return "", nil, err
+	}
+	return path, logs, nil
+}
@@ -0,0 +1,96 @@
+package utilities
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/adapters/repos/db/vector/hnsw"
+	"github.com/weaviate/weaviate/adapters/repos/db/vector/hnsw/commitlog"
+)
+
+func writeTestCommitLog(t *testing.T, path string) {
+	logger := commitlog.NewLogger(path)
+	require.NoError(t, logger.AddNode(1, 0))
+	require.NoError(t, logger.AddNode(2, 1))
+	require.NoError(t, logger.SetEntryPointWithMaxLayer(2, 1))
+	require.NoError(t, logger.AddLinkAtLevel(1, 0, 2))
+	require.NoError(t, logger.ReplaceLinksAtLevel(2, 0, []uint64{1}))
+	require.NoError(t, logger.AddLinksAtLevel(2, 1, []uint64{1, 3, 4}))
+	require.NoError(t, logger.AddTombstone(1))
+	require.NoError(t, logger.DeleteNode(7))
+	require.NoError(t, logger.Close())
+}
+
+func TestInspectCommitLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "1700000000")
+	writeTestCommitLog(t, path)
+
+	stats, err := inspectCommitLogFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, stats.Operations[hnsw.AddNode])
+	assert.Equal(t, 1, stats.Operations[hnsw.SetEntryPointMaxLevel])
+	assert.Equal(t, 1, stats.Operations[hnsw.AddLinkAtLevel])
+	assert.Equal(t, 1, stats.Operations[hnsw.ReplaceLinksAtLevel])
+	assert.Equal(t, 1, stats.Operations[hnsw.AddLinksAtLevel])
+	assert.Equal(t, 1, stats.Operations[hnsw.AddTombstone])
+	assert.Equal(t, 1, stats.Operations[hnsw.DeleteNode])
+	assert.Equal(t, 5, stats.Links)
+	assert.Equal(t, uint64(7), stats.MaxNodeID)
+	assert.Equal(t, map[uint16]int{0: 1, 1: 1}, stats.Levels)
+	assert.Equal(t, 0.5, stats.TombstoneRatio())
+}
+
+func TestReadTruncatedCommitLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "1700000000")
+	writeTestCommitLog(t, path)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-3))
+
+	validLength, err := readCommitLogFile(path, func(commitLogRecord) error { return nil })
+	assert.Error(t, err)
+	// the last record is a 9 byte DeleteNode
+	assert.Equal(t, info.Size()-9, validLength)
+}
+
+func TestVerifyCommitLogs(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "main.hnsw.commitlog.d")
+	require.NoError(t, os.MkdirAll(dir, os.ModePerm))
+
+	writeTestCommitLog(t, filepath.Join(dir, "1700000000.condensed"))
+	writeTestCommitLog(t, filepath.Join(dir, "1700000100"))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "disabled"), nil, 0o644))
+
+	issues, err := verifyCommitLogs(dir, true)
+	require.NoError(t, err)
+	assert.Equal(t, []commitLogIssue{
+		{File: "disabled", Offset: -1, Message: "leftover disabled sentinel, weaviate will not write to this commit log"},
+	}, issues)
+
+	info, err := os.Stat(filepath.Join(dir, "1700000100"))
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(filepath.Join(dir, "1700000100"), info.Size()-3))
+
+	issues, err = verifyCommitLogs(dir, false)
+	require.NoError(t, err)
+	require.Len(t, issues, 2)
+	assert.Equal(t, "1700000100", issues[1].File)
+	assert.Equal(t, info.Size()-9, issues[1].Offset)
+}
+
+func TestCheckCommitLogOrder(t *testing.T) {
+	now := time.Unix(1700001000, 0)
+	assert.Empty(t, checkCommitLogOrder([]string{"1700000000.condensed", "1700000100.condensed", "1700000200", "1700000300"}, now))
+
+	assert.Equal(t, []commitLogIssue{
+		{File: "1700000100", Offset: -1, Message: "overlaps with 1700000100.condensed, an interrupted condense left both files"},
+		{File: "1700000300.condensed", Offset: -1, Message: "out of order, condensed although the older log 1700000200 is not"},
+		{File: "1800000000", Offset: -1, Message: "out of order, named with a timestamp in the future (2027-01-15T08:00:00Z)"},
+	}, checkCommitLogOrder([]string{"1700000000.condensed", "1700000100", "1700000100.condensed", "1700000200", "1700000300.condensed", "1800000000"}, now))
+}
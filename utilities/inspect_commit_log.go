@@ -0,0 +1,202 @@
+package utilities
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/weaviate/weaviate/adapters/repos/db/vector/hnsw"
+)
+
+var inspectCommitLogCmd = &cobra.Command{
+	Use:   "inspect-commit-log <path>",
+	Short: "Summarize the contents of HNSW commit logs",
+	Long:  `Summarize the operations stored in a single HNSW commit log file or a *.hnsw.commitlog.d directory`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := InspectCommitLogs(os.Stdout, filepath.Clean(args[0])); err != nil {
+			log.WithError(err).Fatal("Cannot read commit logs")
+		}
+	},
+}
+
+func NewInspectCommitLogCmd() *cobra.Command {
+	return inspectCommitLogCmd
+}
+
+// InspectCommitLogs writes the summary of a commit log file or directory to
+// out. Files that cannot be read completely are logged and summarized up to
+// the point of corruption.
+func InspectCommitLogs(out io.Writer, path string) error {
+	dir, files, err := resolveCommitLogs(path)
+	if err != nil {
+		return err
+	}
+
+	var stats []*commitLogStats
+	for _, file := range files {
+		fileStats, err := inspectCommitLogFile(filepath.Join(dir, file))
+		if err != nil {
+			log.WithError(err).WithField("file", file).Error("Commit log could not be read completely")
+		}
+		stats = append(stats, fileStats)
+	}
+
+	printCommitLogStats(out, stats)
+	return nil
+}
+
+// commitLogStats summarizes the operations found in one or more commit logs.
+type commitLogStats struct {
+	File              string
+	Size              int64
+	Operations        map[hnsw.HnswCommitType]int
+	Links             int
+	MaxNodeID         uint64
+	Levels            map[uint16]int
+	Tombstones        int
+	RemovedTombstones int
+}
+
+func newCommitLogStats(file string) *commitLogStats {
+	return &commitLogStats{
+		File:       file,
+		Operations: map[hnsw.HnswCommitType]int{},
+		Levels:     map[uint16]int{},
+	}
+}
+
+func (s *commitLogStats) add(record commitLogRecord) {
+	s.Operations[record.Type]++
+	s.Links += record.Links
+
+	switch record.Type {
+	case hnsw.AddNode:
+		s.Levels[record.Level]++
+	case hnsw.AddTombstone:
+		s.Tombstones++
+	case hnsw.RemoveTombstone:
+		s.RemovedTombstones++
+	}
+
+	switch record.Type {
+	case hnsw.ResetIndex, hnsw.AddPQ:
+	default:
+		if record.Node > s.MaxNodeID {
+			s.MaxNodeID = record.Node
+		}
+	}
+}
+
+func (s *commitLogStats) merge(other *commitLogStats) {
+	s.Size += other.Size
+	s.Links += other.Links
+	s.Tombstones += other.Tombstones
+	s.RemovedTombstones += other.RemovedTombstones
+	if other.MaxNodeID > s.MaxNodeID {
+		s.MaxNodeID = other.MaxNodeID
+	}
+	for op, count := range other.Operations {
+		s.Operations[op] += count
+	}
+	for level, count := range other.Levels {
+		s.Levels[level] += count
+	}
+}
+
+// TombstoneRatio is the share of added nodes which are still tombstoned.
+func (s *commitLogStats) TombstoneRatio() float64 {
+	nodes := s.Operations[hnsw.AddNode]
+	if nodes == 0 {
+		return 0
+	}
+	return float64(s.Tombstones-s.RemovedTombstones) / float64(nodes)
+}
+
+func inspectCommitLogFile(path string) (*commitLogStats, error) {
+	stats := newCommitLogStats(filepath.Base(path))
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return stats, err
+	}
+	stats.Size = info.Size()
+
+	_, err = readCommitLogFile(path, func(record commitLogRecord) error {
+		stats.add(record)
+		return nil
+	})
+	return stats, err
+}
+
+var commitTypes = []hnsw.HnswCommitType{
+	hnsw.AddNode,
+	hnsw.SetEntryPointMaxLevel,
+	hnsw.AddLinkAtLevel,
+	hnsw.AddLinksAtLevel,
+	hnsw.ReplaceLinksAtLevel,
+	hnsw.AddTombstone,
+	hnsw.RemoveTombstone,
+	hnsw.ClearLinks,
+	hnsw.ClearLinksAtLevel,
+	hnsw.DeleteNode,
+	hnsw.ResetIndex,
+	hnsw.AddPQ,
+}
+
+func printCommitLogStats(out io.Writer, stats []*commitLogStats) {
+	total := newCommitLogStats("total")
+	for _, fileStats := range stats {
+		total.merge(fileStats)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(w, "file\tsize\t")
+	for _, ct := range commitTypes {
+		fmt.Fprintf(w, "%s\t", ct)
+	}
+	fmt.Fprintln(w, "max node id\t")
+	for _, fileStats := range append(stats, total) {
+		fmt.Fprintf(w, "%s\t%s\t", fileStats.File, FormatBytes(fileStats.Size))
+		for _, ct := range commitTypes {
+			fmt.Fprintf(w, "%d\t", fileStats.Operations[ct])
+		}
+		fmt.Fprintf(w, "%d\t\n", fileStats.MaxNodeID)
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\nFiles: %d, total size: %s\n", len(stats), FormatBytes(total.Size))
+	fmt.Fprintf(out, "Links written: %d\n", total.Links)
+	fmt.Fprintf(out, "Tombstones: %d added, %d removed, ratio %.2f%%\n",
+		total.Tombstones, total.RemovedTombstones, total.TombstoneRatio()*100)
+
+	levels := make([]int, 0, len(total.Levels))
+	for level := range total.Levels {
+		levels = append(levels, int(level))
+	}
+	sort.Ints(levels)
+
+	fmt.Fprintln(out, "Level distribution of added nodes:")
+	for _, level := range levels {
+		fmt.Fprintf(out, "  level %d: %d\n", level, total.Levels[uint16(level)])
+	}
+}
+
+// FormatBytes formats a size in bytes with binary units, e.g. 1.5 GiB.
+func FormatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,297 @@
+package utilities
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/edsrzf/mmap-go"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/weaviate/weaviate/adapters/repos/db/lsmkv/segmentindex"
+)
+
+var inspectLSMCmd = &cobra.Command{
+	Use:   "inspect-lsm <shard path>",
+	Short: "List the LSM buckets and segments of a shard",
+	Long:  `Offline inspection of the LSM store of a shard: every bucket with its segments, their strategy, level, size, key count and bloom filter/CNA presence`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		buckets, err := inspectLSMStore(filepath.Clean(args[0]), inspectLSMConfig.CountKeys)
+		if err != nil {
+			log.WithError(err).Fatal("Cannot inspect LSM store")
+		}
+
+		printLSMBuckets(os.Stdout, buckets)
+
+		findings := lsmFindings(buckets, inspectLSMConfig.MaxSegments)
+		for _, finding := range findings {
+			log.WithField("bucket", finding.Bucket).Warn(finding.Message)
+		}
+	},
+}
+
+type InspectLSMConfig struct {
+	CountKeys   bool
+	MaxSegments int
+}
+
+var inspectLSMConfig InspectLSMConfig
+
+func NewInspectLSMCmd() *cobra.Command {
+	inspectLSMCmd.PersistentFlags().BoolVar(&inspectLSMConfig.CountKeys,
+		"count-keys", true, "Read the primary index of every segment to count its keys")
+
+	inspectLSMCmd.PersistentFlags().IntVar(&inspectLSMConfig.MaxSegments,
+		"max-segments", 50, "Flag buckets with more segments than this")
+
+	return inspectLSMCmd
+}
+
+// InspectLSM writes the bucket table of a shard's LSM store to out, followed
+// by one line per finding.
+func InspectLSM(out io.Writer, path string, countKeys bool, maxSegments int) error {
+	buckets, err := inspectLSMStore(path, countKeys)
+	if err != nil {
+		return err
+	}
+
+	printLSMBuckets(out, buckets)
+
+	findings := lsmFindings(buckets, maxSegments)
+	if len(findings) > 0 {
+		fmt.Fprintln(out)
+	}
+	for _, finding := range findings {
+		fmt.Fprintf(out, "%s: %s\n", finding.Bucket, finding.Message)
+	}
+	return nil
+}
+
+// lsmSegment is a single segment-*.db file of a bucket.
+type lsmSegment struct {
+	Name             string
+	Strategy         string
+	Level            uint16
+	Version          uint16
+	SecondaryIndices uint16
+	Size             int64
+	Keys             int
+	Bloom            bool
+	CNA              bool
+	Err              error
+}
+
+// lsmBucket is a bucket directory below <shard>/lsm.
+type lsmBucket struct {
+	Name       string
+	Segments   []lsmSegment
+	Size       int64
+	WALs       []string
+	TmpFiles   []string
+	Compacting []string
+}
+
+type lsmFinding struct {
+	Bucket  string
+	Message string
+}
+
+var lsmStrategies = map[segmentindex.Strategy]string{
+	segmentindex.StrategyReplace:       "replace",
+	segmentindex.StrategySetCollection: "setcollection",
+	segmentindex.StrategyMapCollection: "mapcollection",
+	segmentindex.StrategyRoaringSet:    "roaringset",
+}
+
+// inspectLSMStore reads every bucket of a shard. The path may point to the
+// shard or directly to its lsm directory.
+func inspectLSMStore(path string, countKeys bool) ([]lsmBucket, error) {
+	lsmPath := filepath.Join(path, "lsm")
+	if _, err := os.Stat(lsmPath); err != nil {
+		lsmPath = path
+	}
+
+	entries, err := os.ReadDir(lsmPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var buckets []lsmBucket
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		bucket, err := inspectLSMBucket(filepath.Join(lsmPath, entry.Name()), countKeys)
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	sort.Slice(buckets, func(a, b int) bool {
+		return buckets[a].Name < buckets[b].Name
+	})
+
+	return buckets, nil
+}
+
+func inspectLSMBucket(path string, countKeys bool) (lsmBucket, error) {
+	bucket := lsmBucket{Name: filepath.Base(path)}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return bucket, fmt.Errorf("failed to read bucket %s: %w", bucket.Name, err)
+	}
+
+	files := map[string]struct{}{}
+	for _, entry := range entries {
+		files[entry.Name()] = struct{}{}
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+
+		if info, err := entry.Info(); err == nil {
+			bucket.Size += info.Size()
+		}
+
+		switch {
+		case strings.HasSuffix(name, ".db.tmp") && strings.Contains(name, "_"):
+			bucket.Compacting = append(bucket.Compacting, name)
+		case strings.HasSuffix(name, ".tmp"):
+			bucket.TmpFiles = append(bucket.TmpFiles, name)
+		case strings.HasSuffix(name, ".wal"):
+			bucket.WALs = append(bucket.WALs, name)
+		case strings.HasSuffix(name, ".db"):
+			segment := inspectLSMSegment(filepath.Join(path, name), countKeys)
+			base := strings.TrimSuffix(name, ".db")
+			_, segment.Bloom = files[base+".bloom"]
+			_, segment.CNA = files[base+".cna"]
+			bucket.Segments = append(bucket.Segments, segment)
+		}
+	}
+
+	return bucket, nil
+}
+
+func inspectLSMSegment(path string, countKeys bool) lsmSegment {
+	segment := lsmSegment{Name: filepath.Base(path)}
+
+	file, err := os.Open(path)
+	if err != nil {
+		segment.Err = err
+		return segment
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		segment.Err = err
+		return segment
+	}
+	segment.Size = info.Size()
+
+	headerBytes := make([]byte, segmentindex.HeaderSize)
+	if _, err := io.ReadFull(file, headerBytes); err != nil {
+		segment.Err = fmt.Errorf("read header: %w", err)
+		return segment
+	}
+	header, err := segmentindex.ParseHeader(bytes.NewReader(headerBytes))
+	if err != nil {
+		segment.Err = fmt.Errorf("parse header: %w", err)
+		return segment
+	}
+
+	segment.Level = header.Level
+	segment.Version = header.Version
+	segment.SecondaryIndices = header.SecondaryIndices
+	segment.Strategy = lsmStrategies[header.Strategy]
+	if segment.Strategy == "" {
+		segment.Err = fmt.Errorf("unsupported strategy %d", header.Strategy)
+		return segment
+	}
+	if header.IndexStart > uint64(segment.Size) {
+		segment.Err = fmt.Errorf("index start %d is beyond the end of the file", header.IndexStart)
+		return segment
+	}
+
+	if !countKeys {
+		return segment
+	}
+
+	// map the file like weaviate does, so large segments do not have to fit
+	// into memory
+	contents, err := mmap.MapRegion(file, int(segment.Size), mmap.RDONLY, 0, 0)
+	if err != nil {
+		segment.Err = fmt.Errorf("mmap file: %w", err)
+		return segment
+	}
+	defer contents.Unmap()
+
+	primaryIndex, err := header.PrimaryIndex(contents)
+	if err != nil {
+		segment.Err = fmt.Errorf("extract primary index: %w", err)
+		return segment
+	}
+
+	keys, err := segmentindex.NewDiskTree(primaryIndex).AllKeys()
+	if err != nil {
+		segment.Err = fmt.Errorf("read primary index: %w", err)
+		return segment
+	}
+	segment.Keys = len(keys)
+
+	return segment
+}
+
+// lsmFindings flags buckets that need attention: too many segments, orphaned
+// temporary files, unfinished compactions and segments that cannot be read.
+func lsmFindings(buckets []lsmBucket, maxSegments int) []lsmFinding {
+	var findings []lsmFinding
+
+	for _, bucket := range buckets {
+		if len(bucket.Segments) > maxSegments {
+			findings = append(findings, lsmFinding{Bucket: bucket.Name,
+				Message: fmt.Sprintf("%d segments, compaction may be falling behind", len(bucket.Segments))})
+		}
+		for _, name := range bucket.Compacting {
+			findings = append(findings, lsmFinding{Bucket: bucket.Name,
+				Message: fmt.Sprintf("unfinished compaction %s", name)})
+		}
+		for _, name := range bucket.TmpFiles {
+			findings = append(findings, lsmFinding{Bucket: bucket.Name,
+				Message: fmt.Sprintf("orphaned temporary file %s", name)})
+		}
+		for _, segment := range bucket.Segments {
+			if segment.Err != nil {
+				findings = append(findings, lsmFinding{Bucket: bucket.Name,
+					Message: fmt.Sprintf("segment %s cannot be read: %s", segment.Name, segment.Err)})
+			}
+		}
+	}
+
+	return findings
+}
+
+func printLSMBuckets(out io.Writer, buckets []lsmBucket) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "bucket\tsegment\tstrategy\tlevel\tsize\tkeys\tbloom\tcna\t")
+	for _, bucket := range buckets {
+		fmt.Fprintf(w, "%s\t%d segments, %d wal\t\t\t%s\t\t\t\t\n",
+			bucket.Name, len(bucket.Segments), len(bucket.WALs), FormatBytes(bucket.Size))
+		for _, segment := range bucket.Segments {
+			fmt.Fprintf(w, "\t%s\t%s\t%d\t%s\t%d\t%t\t%t\t\n", segment.Name, segment.Strategy,
+				segment.Level, FormatBytes(segment.Size), segment.Keys, segment.Bloom, segment.CNA)
+		}
+	}
+	w.Flush()
+}
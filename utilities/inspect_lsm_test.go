@@ -0,0 +1,74 @@
+package utilities
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate/adapters/repos/db/lsmkv/segmentindex"
+)
+
+func writeTestSegment(t *testing.T, path string, keys ...string) {
+	data := []byte("0123456789")
+	nodes := make([]segmentindex.Node, len(keys))
+	for i, key := range keys {
+		nodes[i] = segmentindex.Node{Key: []byte(key), Start: 16, End: 26}
+	}
+	tree := segmentindex.NewBalanced(nodes)
+	index, err := tree.MarshalBinary()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	header := segmentindex.Header{
+		Level:      1,
+		Version:    0,
+		Strategy:   segmentindex.StrategyReplace,
+		IndexStart: uint64(segmentindex.HeaderSize + len(data)),
+	}
+	_, err = header.WriteTo(&buf)
+	require.NoError(t, err)
+	buf.Write(data)
+	buf.Write(index)
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+}
+
+func TestInspectLSMStore(t *testing.T) {
+	shard := t.TempDir()
+	objects := filepath.Join(shard, "lsm", "objects")
+	require.NoError(t, os.MkdirAll(objects, os.ModePerm))
+	require.NoError(t, os.MkdirAll(filepath.Join(shard, "lsm", "property_title"), os.ModePerm))
+
+	writeTestSegment(t, filepath.Join(objects, "segment-1.db"), "a", "b", "c")
+	writeTestSegment(t, filepath.Join(objects, "segment-2.db"), "d")
+	require.NoError(t, os.WriteFile(filepath.Join(objects, "segment-1.bloom"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(objects, "segment-1.cna"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(objects, "segment-1_2.db.tmp"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(objects, "segment-2.bloom.tmp"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(objects, "segment-3.wal"), nil, 0o644))
+
+	buckets, err := inspectLSMStore(shard, true)
+	require.NoError(t, err)
+	require.Len(t, buckets, 2)
+
+	bucket := buckets[0]
+	assert.Equal(t, "objects", bucket.Name)
+	require.Len(t, bucket.Segments, 2)
+	assert.Equal(t, "replace", bucket.Segments[0].Strategy)
+	assert.Equal(t, uint16(1), bucket.Segments[0].Level)
+	assert.Equal(t, 3, bucket.Segments[0].Keys)
+	assert.True(t, bucket.Segments[0].Bloom)
+	assert.True(t, bucket.Segments[0].CNA)
+	assert.Equal(t, 1, bucket.Segments[1].Keys)
+	assert.False(t, bucket.Segments[1].Bloom)
+	assert.Equal(t, []string{"segment-3.wal"}, bucket.WALs)
+
+	findings := lsmFindings(buckets, 1)
+	assert.Equal(t, []lsmFinding{
+		{Bucket: "objects", Message: "2 segments, compaction may be falling behind"},
+		{Bucket: "objects", Message: "unfinished compaction segment-1_2.db.tmp"},
+		{Bucket: "objects", Message: "orphaned temporary file segment-2.bloom.tmp"},
+	}, findings)
+}
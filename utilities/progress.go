@@ -0,0 +1,253 @@
+package utilities
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	log "github.com/sirupsen/logrus"
+)
+
+var logFormat = "text"
+
+// SetLogFormat configures the output of the utilities commands. With "json"
+// every log line and progress update is written as a JSON object, which is
+// easier to follow from Kubernetes job logs.
+func SetLogFormat(format string) error {
+	switch format {
+	case "text":
+		log.SetFormatter(&log.TextFormatter{})
+	case "json":
+		log.SetFormatter(&log.JSONFormatter{})
+	default:
+		return fmt.Errorf("unknown log format %q, must be text or json", format)
+	}
+
+	logFormat = format
+	return nil
+}
+
+// progress tracks a long running operation and periodically reports bytes
+// copied, files processed, shards done and combine/condense iterations. On a
+// terminal a single status line is redrawn, otherwise a log entry is written.
+//
+// The ETA only covers copying, whose volume is known up front. The duration
+// of combining and condensing cannot be estimated, the time spent in it is
+// reported instead.
+type progress struct {
+	sync.Mutex
+	name        string
+	totalBytes  int64
+	bytes       int64
+	totalFiles  int
+	files       int
+	totalShards int
+	shards      int
+	iterations  int
+	started     time.Time
+	// combining is the number of shards in the combine and condense loop,
+	// which is running since combineStarted. combineTime is the time spent
+	// in earlier runs of the loop.
+	combining      int
+	combineStarted time.Time
+	combineTime    time.Duration
+
+	out  io.Writer
+	tty  bool
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newProgress(name string) *progress {
+	return &progress{
+		name:    name,
+		started: time.Now(),
+		out:     os.Stderr,
+		tty:     logFormat == "text" && isatty.IsTerminal(os.Stderr.Fd()),
+	}
+}
+
+func (p *progress) addTotal(bytes int64, files int, shards int) {
+	if p == nil {
+		return
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.totalBytes += bytes
+	p.totalFiles += files
+	p.totalShards += shards
+}
+
+func (p *progress) addBytes(n int64) {
+	if p == nil {
+		return
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.bytes += n
+}
+
+func (p *progress) fileDone() {
+	if p == nil {
+		return
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.files++
+}
+
+func (p *progress) shardDone() {
+	if p == nil {
+		return
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.shards++
+}
+
+func (p *progress) iterationDone() {
+	if p == nil {
+		return
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.iterations++
+}
+
+// combineStart marks a shard entering the combine and condense loop.
+func (p *progress) combineStart() {
+	if p == nil {
+		return
+	}
+	p.Lock()
+	defer p.Unlock()
+	if p.combining == 0 {
+		p.combineStarted = time.Now()
+	}
+	p.combining++
+}
+
+// combineDone marks a shard leaving the combine and condense loop.
+func (p *progress) combineDone() {
+	if p == nil {
+		return
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.combining--
+	if p.combining == 0 {
+		p.combineTime += time.Since(p.combineStarted)
+	}
+}
+
+// copyEta estimates the time left for copying from the rate bytes have been
+// copied at outside of the combine phase. It returns false while there is not
+// enough data for an estimate or nothing is left to copy.
+func (p *progress) copyEta(now time.Time) (time.Duration, bool) {
+	copying := now.Sub(p.started) - p.combineTime
+	if p.combining > 0 {
+		copying -= now.Sub(p.combineStarted)
+	}
+	remaining := p.totalBytes - p.bytes
+	if p.bytes == 0 || remaining <= 0 || copying <= 0 {
+		return 0, false
+	}
+	rate := float64(p.bytes) / copying.Seconds()
+	return time.Duration(float64(remaining) / rate * float64(time.Second)), true
+}
+
+func (p *progress) report() {
+	p.Lock()
+	defer p.Unlock()
+
+	now := time.Now()
+	eta, hasEta := p.copyEta(now)
+
+	if !p.tty {
+		fields := log.Fields{
+			"bytes":      p.bytes,
+			"totalBytes": p.totalBytes,
+			"files":      p.files,
+			"totalFiles": p.totalFiles,
+			"iterations": p.iterations,
+			"elapsed":    now.Sub(p.started).Round(time.Second).String(),
+		}
+		if p.totalShards > 0 {
+			fields["shards"] = p.shards
+			fields["totalShards"] = p.totalShards
+		}
+		if hasEta {
+			fields["copyEta"] = eta.Round(time.Second).String()
+		}
+		if p.combining > 0 {
+			fields["combining"] = p.combining
+			fields["combineElapsed"] = now.Sub(p.combineStarted).Round(time.Second).String()
+		}
+		log.WithFields(fields).Info(p.name)
+		return
+	}
+
+	parts := []string{
+		fmt.Sprintf("copied %s/%s", FormatBytes(p.bytes), FormatBytes(p.totalBytes)),
+		fmt.Sprintf("files %d/%d", p.files, p.totalFiles),
+	}
+	if p.totalShards > 0 {
+		parts = append(parts, fmt.Sprintf("shards %d/%d", p.shards, p.totalShards))
+	}
+	parts = append(parts, fmt.Sprintf("iterations %d", p.iterations))
+	if hasEta {
+		parts = append(parts, fmt.Sprintf("copy ETA %s", eta.Round(time.Second)))
+	}
+	if p.combining > 0 {
+		parts = append(parts, fmt.Sprintf("combining %d for %s", p.combining, now.Sub(p.combineStarted).Round(time.Second)))
+	}
+	parts = append(parts, fmt.Sprintf("elapsed %s", now.Sub(p.started).Round(time.Second)))
+	fmt.Fprintf(p.out, "\r\033[K%s: %s", p.name, strings.Join(parts, ", "))
+}
+
+// start reports progress every interval until finish is called.
+func (p *progress) start(interval time.Duration) {
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.report()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// finish stops the periodic reports and writes a final one.
+func (p *progress) finish() {
+	if p.stop != nil {
+		close(p.stop)
+		<-p.done
+	}
+	p.report()
+	if p.tty {
+		fmt.Fprintln(p.out)
+	}
+}
+
+// progressWriter counts the bytes written through it.
+type progressWriter struct {
+	w        io.Writer
+	progress *progress
+}
+
+func (w progressWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	w.progress.addBytes(int64(n))
+	return n, err
+}
@@ -0,0 +1,120 @@
+package utilities
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressCopyEta(t *testing.T) {
+	p := newProgress("test")
+	p.started = time.Now().Add(-10 * time.Second)
+
+	_, ok := p.copyEta(time.Now())
+	assert.False(t, ok)
+
+	p.addTotal(400, 4, 0)
+	p.addBytes(100)
+	eta, ok := p.copyEta(p.started.Add(10 * time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, eta)
+
+	// time spent combining does not slow down the copy rate
+	p.combineTime = 20 * time.Second
+	eta, ok = p.copyEta(p.started.Add(30 * time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, eta)
+
+	p.addBytes(300)
+	_, ok = p.copyEta(p.started.Add(30 * time.Second))
+	assert.False(t, ok)
+}
+
+func TestProgressTTYReport(t *testing.T) {
+	var out bytes.Buffer
+	p := newProgress("combine")
+	p.out = &out
+	p.tty = true
+	p.addTotal(2048, 2, 1)
+	p.addBytes(1024)
+	p.fileDone()
+	p.iterationDone()
+
+	p.started = time.Now().Add(-10 * time.Second)
+	p.report()
+	assert.Contains(t, out.String(), "combine: copied 1.0 KiB/2.0 KiB, files 1/2, shards 0/1, iterations 1, copy ETA 10s, elapsed 10s")
+
+	p.combineStart()
+	p.combineStart()
+	p.combineStarted = time.Now().Add(-90 * time.Second)
+	p.report()
+	assert.Contains(t, out.String(), "iterations 1, combining 2 for 1m30s, elapsed")
+
+	p.combineDone()
+	p.combineDone()
+	out.Reset()
+	p.report()
+	assert.NotContains(t, out.String(), "combining")
+}
+
+// fakeCombiner reports changes for the given number of calls of each method.
+type fakeCombiner struct {
+	combines, condenses int
+	progress            *progress
+	t                   *testing.T
+}
+
+func (f *fakeCombiner) CombineLogs() (bool, error) {
+	assert.Equal(f.t, 1, f.progress.combining)
+	f.combines--
+	return f.combines >= 0, nil
+}
+
+func (f *fakeCombiner) CondenseOldLogs() (bool, error) {
+	f.condenses--
+	return f.condenses >= 0, nil
+}
+
+func TestCombineAndCondense(t *testing.T) {
+	p := newProgress("combine")
+	require.NoError(t, combineAndCondense(&fakeCombiner{combines: 3, condenses: 1, progress: p, t: t}, "main.hnsw.commitlog.d", p))
+	// the first iteration combines three times and condenses once, the
+	// second one finds nothing left to do
+	assert.Equal(t, 2, p.iterations)
+	assert.Zero(t, p.combining)
+}
+
+func TestCombineCommitLogsProgress(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(base, "main.hnsw.commitlog.d")
+	require.NoError(t, os.MkdirAll(dir, os.ModePerm))
+	for i := 0; i < 12; i++ {
+		writeTestCommitLog(t, filepath.Join(dir, fmt.Sprintf("17000000%02d", i)))
+	}
+	require.NoError(t, createSentinelFile(dir))
+
+	p := newProgress("combine")
+	require.NoError(t, combineCommitLogs(base, "main", p))
+
+	// the last 10 files are not touched, the other two are copied into the
+	// working and the backup folder, then the combined files are copied back
+	info, err := os.Stat(filepath.Join(dir, "1700000011"))
+	require.NoError(t, err)
+	logs, _, err := listCommitLogs(dir)
+	require.NoError(t, err)
+	combined := logs[:len(logs)-10]
+	combinedSize, err := commitLogsSize(dir, combined)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4+len(combined), p.totalFiles)
+	assert.Equal(t, p.totalFiles, p.files)
+	assert.Equal(t, 4*info.Size()+combinedSize, p.totalBytes)
+	assert.Equal(t, p.totalBytes, p.bytes)
+	assert.Positive(t, p.iterations)
+}
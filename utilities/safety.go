@@ -0,0 +1,225 @@
+package utilities
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+// processInfo describes a live process that holds a file below a watched
+// directory open.
+type processInfo struct {
+	Pid     int
+	Command string
+	Path    string
+}
+
+// findProcessesUsingPath scans /proc for processes that have a file
+// descriptor, working directory or memory mapping pointing into path. It only
+// works on Linux, on other systems an error is returned.
+func findProcessesUsingPath(procRoot string, path string) ([]processInfo, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	// weaviate may hold the directory open through a symlink, so compare the
+	// resolved path as well
+	resolvedPath, err := filepath.EvalSymlinks(absPath)
+	if err != nil {
+		resolvedPath = absPath
+	}
+
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("cannot scan %s for open files: %w", procRoot, err)
+	}
+
+	self := os.Getpid()
+	var processes []processInfo
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == self {
+			continue
+		}
+
+		pidPath := filepath.Join(procRoot, entry.Name())
+		match, ok := processPathMatch(pidPath, absPath, resolvedPath)
+		if !ok {
+			continue
+		}
+
+		processes = append(processes, processInfo{
+			Pid:     pid,
+			Command: processCommand(pidPath),
+			Path:    match,
+		})
+	}
+
+	return processes, nil
+}
+
+func processPathMatch(pidPath string, paths ...string) (string, bool) {
+	// processes we are not allowed to inspect are skipped silently, they
+	// cannot belong to the user running the tool anyway unless run as root
+	if cwd, err := os.Readlink(filepath.Join(pidPath, "cwd")); err == nil && isBelow(cwd, paths...) {
+		return cwd, true
+	}
+
+	fds, err := os.ReadDir(filepath.Join(pidPath, "fd"))
+	if err == nil {
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(pidPath, "fd", fd.Name()))
+			if err != nil {
+				continue
+			}
+			if isBelow(target, paths...) {
+				return target, true
+			}
+		}
+	}
+
+	maps, err := os.ReadFile(filepath.Join(pidPath, "maps"))
+	if err == nil {
+		for _, line := range strings.Split(string(maps), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 6 {
+				continue
+			}
+			if isBelow(fields[5], paths...) {
+				return fields[5], true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func processCommand(pidPath string) string {
+	comm, err := os.ReadFile(filepath.Join(pidPath, "comm"))
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(comm))
+}
+
+func isBelow(target string, paths ...string) bool {
+	target = strings.TrimSuffix(target, " (deleted)")
+	for _, path := range paths {
+		if target == path || strings.HasPrefix(target, path+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWeaviateDown asks a Weaviate instance whether the node owning the files
+// is still serving requests. It returns nil only if every shard of that node is
+// READONLY, meaning it is safe to touch files on disk. An empty node name is
+// allowed for single node clusters.
+func checkWeaviateDown(weaviateUrl string, apiKey string, nodeName string) error {
+	client := &http.Client{Timeout: 10 * time.Second}
+	baseUrl := strings.TrimSuffix(weaviateUrl, "/")
+
+	req, err := http.NewRequest(http.MethodGet, baseUrl+"/v1/nodes?output=verbose", nil)
+	if err != nil {
+		return fmt.Errorf("cannot parse Weaviate url: %w", err)
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("weaviate at %s is not reachable, cannot confirm it is down: %w", weaviateUrl, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("weaviate at %s is up and returned %s for /v1/nodes", weaviateUrl, resp.Status)
+	}
+
+	var nodes models.NodesStatusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&nodes); err != nil {
+		return fmt.Errorf("cannot parse Weaviate /v1/nodes: %w", err)
+	}
+
+	node, err := targetNode(nodes.Nodes, nodeName)
+	if err != nil {
+		return fmt.Errorf("weaviate at %s is up and %w", weaviateUrl, err)
+	}
+
+	if len(node.Shards) == 0 {
+		return fmt.Errorf("weaviate at %s is up and node %s reports no shards, cannot confirm they are READONLY",
+			weaviateUrl, node.Name)
+	}
+	for _, shard := range node.Shards {
+		if shard.VectorIndexingStatus != "READONLY" {
+			return fmt.Errorf("weaviate at %s is up and shard %s/%s on node %s is %s",
+				weaviateUrl, shard.Class, shard.Name, node.Name, shard.VectorIndexingStatus)
+		}
+	}
+
+	log.WithField("url", weaviateUrl).WithField("node", node.Name).Info("Weaviate is up but all shards of the node are READONLY")
+	return nil
+}
+
+// targetNode picks the node owning the files. Without a name the cluster must
+// have exactly one node.
+func targetNode(nodes []*models.NodeStatus, name string) (*models.NodeStatus, error) {
+	if name == "" {
+		if len(nodes) != 1 {
+			return nil, fmt.Errorf("has %d nodes, pass --node to select the one owning the files", len(nodes))
+		}
+		return nodes[0], nil
+	}
+	for _, node := range nodes {
+		if node.Name == name {
+			return node, nil
+		}
+	}
+	return nil, fmt.Errorf("has no node %s", name)
+}
+
+// checkSafeToModify refuses to continue if the commit log directory is held
+// open by a live process or the given Weaviate node is still accepting writes
+// or cannot be asked. With force set the findings are logged and ignored.
+func checkSafeToModify(path string, weaviateUrl string, apiKey string, nodeName string, force bool) error {
+	var problems []string
+
+	processes, err := findProcessesUsingPath("/proc", path)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("cannot determine whether the directory is in use: %s", err))
+	}
+	for _, process := range processes {
+		problems = append(problems, fmt.Sprintf("process %d (%s) has %s open", process.Pid, process.Command, process.Path))
+	}
+
+	if weaviateUrl != "" {
+		if err := checkWeaviateDown(weaviateUrl, apiKey, nodeName); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	for _, problem := range problems {
+		log.WithField("path", path).Warn(problem)
+	}
+
+	if force {
+		log.Warn("--force given, continuing regardless")
+		return nil
+	}
+
+	return fmt.Errorf("refusing to modify %s while it may be in use, stop Weaviate first or pass --force", path)
+}
@@ -0,0 +1,59 @@
+package utilities
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindProcessesUsingPath(t *testing.T) {
+	dataPath := filepath.Join(t.TempDir(), "main.hnsw.commitlog.d")
+	require.NoError(t, os.MkdirAll(dataPath, os.ModePerm))
+
+	procRoot := t.TempDir()
+	// pid 100 holds a commit log open, pid 200 holds something unrelated
+	require.NoError(t, os.MkdirAll(filepath.Join(procRoot, "100", "fd"), os.ModePerm))
+	require.NoError(t, os.Symlink(filepath.Join(dataPath, "1700000000"), filepath.Join(procRoot, "100", "fd", "3")))
+	require.NoError(t, os.WriteFile(filepath.Join(procRoot, "100", "comm"), []byte("weaviate\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(procRoot, "200", "fd"), os.ModePerm))
+	require.NoError(t, os.Symlink("/dev/null", filepath.Join(procRoot, "200", "fd", "0")))
+	require.NoError(t, os.MkdirAll(filepath.Join(procRoot, "self"), os.ModePerm))
+
+	processes, err := findProcessesUsingPath(procRoot, dataPath)
+	require.NoError(t, err)
+	assert.Equal(t, []processInfo{
+		{Pid: 100, Command: "weaviate", Path: filepath.Join(dataPath, "1700000000")},
+	}, processes)
+}
+
+func TestCheckSafeToModifyUnusedPath(t *testing.T) {
+	err := checkSafeToModify(t.TempDir(), "", "", "", false)
+	assert.NoError(t, err)
+}
+
+func TestCheckWeaviateDown(t *testing.T) {
+	nodes := `{"nodes": [
+		{"name": "weaviate-0", "shards": [{"class": "Article", "name": "abc", "vectorIndexingStatus": "READONLY"}]},
+		{"name": "weaviate-1", "shards": [{"class": "Article", "name": "def", "vectorIndexingStatus": "READY"}]},
+		{"name": "weaviate-2", "shards": []}
+	]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(nodes))
+	}))
+	defer server.Close()
+
+	assert.NoError(t, checkWeaviateDown(server.URL, "", "weaviate-0"))
+	assert.ErrorContains(t, checkWeaviateDown(server.URL, "", "weaviate-1"), "shard Article/def on node weaviate-1 is READY")
+	assert.ErrorContains(t, checkWeaviateDown(server.URL, "", "weaviate-2"), "node weaviate-2 reports no shards")
+	assert.ErrorContains(t, checkWeaviateDown(server.URL, "", "weaviate-3"), "has no node weaviate-3")
+	assert.ErrorContains(t, checkWeaviateDown(server.URL, "", ""), "has 3 nodes, pass --node")
+
+	url := server.URL
+	server.Close()
+	assert.ErrorContains(t, checkWeaviateDown(url, "", "weaviate-0"), "is not reachable")
+}
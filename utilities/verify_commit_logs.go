@@ -0,0 +1,217 @@
+package utilities
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/weaviate/weaviate/adapters/repos/db/vector/hnsw"
+)
+
+var verifyCommitLogsCmd = &cobra.Command{
+	Use:   "verify-commit-logs <path>",
+	Short: "Check HNSW commit logs for corruption",
+	Long:  `Fully read every file of a *.hnsw.commitlog.d directory and report corrupted or truncated records, misordered files and leftover sentinels`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		commitLogPath := filepath.Clean(args[0])
+		log.WithField("path", commitLogPath).Info("Verifying commit logs")
+
+		issues, err := verifyCommitLogs(commitLogPath, verifyConfig.Load)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to verify commit logs")
+		}
+
+		for _, issue := range issues {
+			entry := log.WithField("file", issue.File)
+			if issue.Offset >= 0 {
+				entry = entry.WithField("offset", issue.Offset)
+			}
+			entry.Warn(issue.Message)
+		}
+
+		if len(issues) > 0 {
+			log.Fatalf("Found %d issues in %s", len(issues), commitLogPath)
+		}
+		log.Info("No issues found")
+	},
+}
+
+type VerifyConfig struct {
+	Load bool
+}
+
+var verifyConfig VerifyConfig
+
+func NewVerifyCommitLogsCmd() *cobra.Command {
+	verifyCommitLogsCmd.PersistentFlags().BoolVar(&verifyConfig.Load,
+		"load", false, "Also build the in-memory graph to confirm the commit logs load")
+
+	return verifyCommitLogsCmd
+}
+
+// commitLogIssue is a problem found while verifying a commit log directory.
+// Offset is -1 if the issue is not tied to a position in the file.
+type commitLogIssue struct {
+	File    string
+	Offset  int64
+	Message string
+}
+
+func verifyCommitLogs(path string, load bool) ([]commitLogIssue, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("path must be a folder: %s", path)
+	}
+
+	logs, other, err := listCommitLogs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []commitLogIssue
+	for _, name := range other {
+		switch {
+		case name == "disabled":
+			issues = append(issues, commitLogIssue{File: name, Offset: -1,
+				Message: "leftover disabled sentinel, weaviate will not write to this commit log"})
+		case strings.HasSuffix(name, ".tmp"):
+			issues = append(issues, commitLogIssue{File: name, Offset: -1,
+				Message: "leftover temporary file from an interrupted combine or condense"})
+		default:
+			issues = append(issues, commitLogIssue{File: name, Offset: -1,
+				Message: "unexpected file, weaviate will fail to parse its name as a timestamp"})
+		}
+	}
+
+	issues = append(issues, checkCommitLogOrder(logs, time.Now())...)
+
+	for i, name := range logs {
+		file := filepath.Join(path, name)
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+
+		validLength, err := readCommitLogFile(file, func(commitLogRecord) error { return nil })
+		if err == nil {
+			continue
+		}
+
+		message := fmt.Sprintf("corrupted record, %d of %d bytes are valid: %s", validLength, info.Size(), err)
+		if i == len(logs)-1 {
+			// weaviate truncates a corrupted tail of the most recent log on startup
+			message = fmt.Sprintf("truncated tail record in the most recent log, %d of %d bytes are valid: %s",
+				validLength, info.Size(), err)
+		}
+		issues = append(issues, commitLogIssue{File: name, Offset: validLength, Message: message})
+	}
+
+	if load {
+		loadIssues, err := loadCommitLogs(path, logs)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, loadIssues...)
+	}
+
+	return issues, nil
+}
+
+// checkCommitLogOrder reports files which overlap because they exist both in
+// condensed and uncondensed form, condensed files following an older
+// uncondensed one and files named with a timestamp in the future. Weaviate
+// condenses logs oldest first and only ever writes to the most recent one, so
+// either means the files were copied or restored from different points in
+// time, or logs written later will sort before older ones.
+func checkCommitLogOrder(logs []string, now time.Time) []commitLogIssue {
+	var issues []commitLogIssue
+
+	names := map[string]struct{}{}
+	for _, name := range logs {
+		names[name] = struct{}{}
+	}
+
+	// the most recent uncondensed log seen so far
+	uncondensed := ""
+	for _, name := range logs {
+		if !strings.HasSuffix(name, ".condensed") {
+			if _, ok := names[name+".condensed"]; ok {
+				issues = append(issues, commitLogIssue{File: name, Offset: -1,
+					Message: fmt.Sprintf("overlaps with %s.condensed, an interrupted condense left both files", name)})
+			} else {
+				uncondensed = name
+			}
+		} else if uncondensed != "" {
+			issues = append(issues, commitLogIssue{File: name, Offset: -1,
+				Message: fmt.Sprintf("out of order, condensed although the older log %s is not", uncondensed)})
+		}
+
+		ts, err := commitLogTimestamp(name)
+		if err == nil && ts > now.Unix() {
+			issues = append(issues, commitLogIssue{File: name, Offset: -1,
+				Message: fmt.Sprintf("out of order, named with a timestamp in the future (%s)", time.Unix(ts, 0).UTC().Format(time.RFC3339))})
+		}
+	}
+
+	return issues
+}
+
+// loadCommitLogs deserializes all logs in order the same way weaviate does on
+// startup to confirm the graph can be built.
+func loadCommitLogs(path string, logs []string) ([]commitLogIssue, error) {
+	var issues []commitLogIssue
+	var state *hnsw.DeserializationResult
+
+	deserializer := hnsw.NewDeserializer(log.New())
+	for _, name := range logs {
+		file, err := os.Open(filepath.Join(path, name))
+		if err != nil {
+			return nil, err
+		}
+
+		result, validLength, err := deserializer.Do(bufio.NewReaderSize(file, 1024*1024), state, false)
+		file.Close()
+		if err != nil {
+			issues = append(issues, commitLogIssue{File: name, Offset: int64(validLength),
+				Message: fmt.Sprintf("graph could not be loaded: %s", err)})
+			if result == nil {
+				return issues, nil
+			}
+		}
+		state = result
+	}
+
+	if state == nil {
+		return issues, nil
+	}
+
+	nodes := 0
+	for _, node := range state.Nodes {
+		if node != nil {
+			nodes++
+		}
+	}
+	log.WithFields(log.Fields{
+		"nodes":      nodes,
+		"tombstones": len(state.Tombstones),
+		"entrypoint": state.Entrypoint,
+		"level":      state.Level,
+		"compressed": state.Compressed,
+	}).Info("Loaded graph")
+
+	if nodes > 0 && (int(state.Entrypoint) >= len(state.Nodes) || state.Nodes[state.Entrypoint] == nil) {
+		issues = append(issues, commitLogIssue{File: logs[len(logs)-1], Offset: -1,
+			Message: fmt.Sprintf("entrypoint %d does not exist in the loaded graph", state.Entrypoint)})
+	}
+
+	return issues, nil
+}